@@ -0,0 +1,49 @@
+// Command import converts CSV and Parquet candle exports into the local candle store.
+//
+// The source directory holds one directory per symbol: <source>/<SYMBOL>/*.csv|*.parquet.
+// Every file is written as EODHD formatted JSON to <store>/<SYMBOL>/<file name>.json, and files
+// that would be written to the same JSON file, like X.csv and X.parquet, stop the import.
+package main
+
+import (
+	"flag"
+	"log"
+	"trend-hencher-api/marketdata"
+)
+
+func main() {
+	source := flag.String("source", "", "directory with one subdirectory of CSV/Parquet files per symbol")
+	store := flag.String("store", "testdata", "local candle store directory")
+	formatPath := flag.String("format", "", "JSON file with column mapping, timestamp format and timezone")
+	timestampFormat := flag.String("timestamp-format", "", "unix, unix_ms or a Go time layout (overrides -format)")
+	timezone := flag.String("timezone", "", "timezone of timestamps without offset (overrides -format)")
+	flag.Parse()
+
+	if *source == "" {
+		log.Fatal("Missing -source directory")
+	}
+
+	format := marketdata.DefaultFileFormat()
+	if *formatPath != "" {
+		var err error
+		format, err = marketdata.LoadFileFormat(*formatPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *timestampFormat != "" {
+		format.TimestampFormat = *timestampFormat
+	}
+	if *timezone != "" {
+		format.Timezone = *timezone
+	}
+
+	results, err := marketdata.ImportDirectory(*source, *store, format)
+	for _, result := range results {
+		log.Printf("Imported %d candles for %s from %s to %s", result.Candles, result.Symbol, result.Source, result.Target)
+	}
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	log.Printf("Imported %d files", len(results))
+}
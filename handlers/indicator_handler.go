@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"trend-hencher-api/marketdata"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+type IndicatorSeriesResponse struct {
+	Symbol          string                `json:"symbol"`
+	Prices          string                `json:"prices"`
+	AdjustmentError string                `json:"adjustment_error,omitempty"`
+	Candles         []models.IntradayData `json:"candles"`
+	Series          map[string][]float64  `json:"series"`
+}
+
+// GetIndicatorSeries returns the candles of a symbol with the requested indicator series, e.g.
+// /indicators?symbol=AAPL&indicators=SMA:20,RSI:14:hlc3,VWAP&from=2025-06-18&to=2025-06-18&maxPoints=500&format=csv.
+// The candles are checked and adjusted like those of /checkMarket, with the same prices, quality and gaps options.
+func (h *TrendHandler) GetIndicatorSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	stockSymbol := query.Get("symbol")
+	if stockSymbol == "" {
+		http.Error(w, "Missing stock symbol", http.StatusBadRequest)
+		return
+	}
+	symbol, err := marketdata.ParseSymbol(stockSymbol)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	indicators, err := parseIndicators(query.Get("indicators"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	interval := query.Get("interval")
+	if err := marketdata.ValidateInterval(interval); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseDateRange(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.parseDataOptions(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	maxPoints := 0
+	if value := query.Get("maxPoints"); value != "" {
+		maxPoints, err = strconv.Atoi(value)
+		if err != nil || maxPoints < 1 {
+			http.Error(w, "Invalid maxPoints", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fetched from before the range so the indicators are warmed up when it starts, twice the longest
+	// period covers indicators smoothed twice like the ADX
+	longest := 0
+	for _, indicator := range indicators {
+		longest = max(longest, indicator.IndicatorPeriod)
+	}
+	warmupFrom := marketdata.WarmupStart(from, 2*longest, interval, symbol.Exchange)
+
+	// The same checked and adjusted candles as /checkMarket, so the series match what was scored
+	market, err := h.loadMarketData(marketdata.DataRequest{Symbol: stockSymbol, Interval: interval, From: warmupFrom, To: to}, symbol, options)
+	if errors.Is(err, marketdata.ErrDataQuality) {
+		http.Error(w, "Rejected data: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading data; %v", err)
+		http.Error(w, err.Error(), marketDataStatus(err))
+		return
+	}
+	intradayData := market.candles
+
+	sessionStarts, err := marketdata.SessionStarts(intradayData, symbol.Exchange)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Indicators are calculated on all data so the range doesn't cut off their warm-up
+	series, err := calculateIndicatorSeries(intradayData, indicators, sessionStarts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	candles, series := filterDateRange(intradayData, series, from, to)
+	candles, series = downsample(candles, series, maxPoints)
+
+	if query.Get("format") == "csv" {
+		writeIndicatorCSV(w, candles, indicators, series)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, IndicatorSeriesResponse{
+		Symbol:          stockSymbol,
+		Prices:          market.prices,
+		AdjustmentError: market.adjustmentError,
+		Candles:         candles,
+		Series:          series,
+	})
+}
+
+// Parses "NAME:PERIOD:SOURCE" entries separated by commas, period and source are optional
+func parseIndicators(value string) ([]models.Indicator, error) {
+	var indicators []models.Indicator
+	if value == "" {
+		return indicators, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if parts[0] == "" || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid indicator %q", entry)
+		}
+
+		indicator := models.Indicator{IndicatorName: strings.ToUpper(parts[0])}
+		if indicator.IndicatorName == "DATA" {
+			indicator.IndicatorName = "Data"
+		}
+		if len(parts) > 1 && parts[1] != "" {
+			period, err := strconv.Atoi(parts[1])
+			if err != nil || period < 0 {
+				return nil, fmt.Errorf("invalid period for indicator %q", entry)
+			}
+			indicator.IndicatorPeriod = period
+		}
+		if len(parts) > 2 {
+			indicator.IndicatorSource = models.PriceSource(parts[2])
+			if !indicator.IndicatorSource.Valid() {
+				return nil, fmt.Errorf("unknown price source for indicator %q", entry)
+			}
+		}
+		indicators = append(indicators, indicator)
+	}
+
+	return indicators, nil
+}
+
+// Name of the series in the response, e.g. SMA(20) or RSI(14,hlc3)
+func seriesName(indicator models.Indicator) string {
+	return indicator.Key().String()
+}
+
+// Calculates the indicators through the same cache used when running scenarios, an indicator
+// the cache doesn't know is an error rather than a missing series
+func calculateIndicatorSeries(data []models.IntradayData, indicators []models.Indicator, sessionStarts []bool) (map[string][]float64, error) {
+	buyScenario := models.BuyScenario{}
+	for _, indicator := range indicators {
+		buyScenario.Conditions = append(buyScenario.Conditions, models.BuyCondition{
+			IndicatorName:   indicator.IndicatorName,
+			IndicatorPeriod: indicator.IndicatorPeriod,
+			IndicatorSource: indicator.IndicatorSource,
+		})
+	}
+
+	indicatorCache, err := models.GetPredefinedIndicators(buyScenario, models.SellScenario{}, nil, data, sessionStarts)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make(map[string][]float64)
+	for _, indicator := range indicators {
+		values, exists := indicatorCache[indicator.Key()]
+		if !exists {
+			return nil, fmt.Errorf("unknown indicator %q", seriesName(indicator))
+		}
+
+		// NaN and infinity can't be encoded as JSON
+		cleaned := make([]float64, len(values))
+		for i, value := range values {
+			if !math.IsNaN(value) && !math.IsInf(value, 0) {
+				cleaned[i] = value
+			}
+		}
+		series[seriesName(indicator)] = cleaned
+	}
+	return series, nil
+}
+
+// Parses from/to dates (2006-01-02), to includes the whole day. Zero times mean no limit.
+func parseDateRange(fromValue, toValue string) (from, to time.Time, err error) {
+	if fromValue != "" {
+		from, err = time.Parse("2006-01-02", fromValue)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if toValue != "" {
+		to, err = time.Parse("2006-01-02", toValue)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to date, expected YYYY-MM-DD")
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("from date must be before to date")
+	}
+	return from, to, nil
+}
+
+func filterDateRange(data []models.IntradayData, series map[string][]float64, from, to time.Time) ([]models.IntradayData, map[string][]float64) {
+	start, end := 0, len(data)
+	for start < end && !from.IsZero() && data[start].Timestamp < from.Unix() {
+		start++
+	}
+	for end > start && !to.IsZero() && data[end-1].Timestamp >= to.Unix() {
+		end--
+	}
+
+	filtered := make(map[string][]float64)
+	for name, values := range series {
+		filtered[name] = values[start:end]
+	}
+	return data[start:end], filtered
+}
+
+// Merges candles into buckets so at most maxPoints are returned, indicators keep the last value of each bucket
+func downsample(data []models.IntradayData, series map[string][]float64, maxPoints int) ([]models.IntradayData, map[string][]float64) {
+	if maxPoints <= 0 || len(data) <= maxPoints {
+		return data, series
+	}
+
+	bucketSize := (len(data) + maxPoints - 1) / maxPoints
+
+	candles := []models.IntradayData{}
+	for start := 0; start < len(data); start += bucketSize {
+		end := min(start+bucketSize, len(data))
+
+		candle := data[start]
+		for _, entry := range data[start+1 : end] {
+			candle.High = math.Max(candle.High, entry.High)
+			candle.Low = math.Min(candle.Low, entry.Low)
+			candle.Close = entry.Close
+			candle.Volume += entry.Volume
+		}
+		candles = append(candles, candle)
+	}
+
+	downsampled := make(map[string][]float64)
+	for name, values := range series {
+		for end := bucketSize; end < len(values)+bucketSize; end += bucketSize {
+			downsampled[name] = append(downsampled[name], values[min(end, len(values))-1])
+		}
+	}
+
+	return candles, downsampled
+}
+
+func writeIndicatorCSV(w http.ResponseWriter, candles []models.IntradayData, indicators []models.Indicator, series map[string][]float64) {
+	names := []string{}
+	for _, indicator := range indicators {
+		if _, exists := series[seriesName(indicator)]; exists {
+			names = append(names, seriesName(indicator))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(append([]string{"timestamp", "datetime", "open", "high", "low", "close", "volume"}, names...))
+	for i, candle := range candles {
+		row := []string{
+			strconv.FormatInt(candle.Timestamp, 10),
+			candle.Datetime,
+			strconv.FormatFloat(candle.Open, 'f', -1, 64),
+			strconv.FormatFloat(candle.High, 'f', -1, 64),
+			strconv.FormatFloat(candle.Low, 'f', -1, 64),
+			strconv.FormatFloat(candle.Close, 'f', -1, 64),
+			strconv.Itoa(candle.Volume),
+		}
+		for _, name := range names {
+			row = append(row, strconv.FormatFloat(series[name][i], 'f', -1, 64))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+}
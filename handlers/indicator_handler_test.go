@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"trend-hencher-api/marketdata"
+	"trend-hencher-api/models"
+)
+
+// 2025-06-18 at 13:30 UTC (9:30 ET)
+const marketOpen = int64(1750253400)
+
+// Minute candles with closes 1, 2, 3... and high and low 1 around the close
+func testCandles(count int) []models.IntradayData {
+	data := make([]models.IntradayData, count)
+	for i := range data {
+		price := float64(i + 1)
+		data[i] = models.IntradayData{Timestamp: marketOpen + int64(i)*60, Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 100}
+	}
+	return data
+}
+
+func TestParseIndicators(t *testing.T) {
+	indicators, err := parseIndicators("sma:20, RSI:14:hlc3,VWAP,data::open")
+	if err != nil {
+		t.Fatalf("parseIndicators should not give error; got: %s", err.Error())
+	}
+
+	expected := []models.Indicator{
+		{IndicatorName: "SMA", IndicatorPeriod: 20},
+		{IndicatorName: "RSI", IndicatorPeriod: 14, IndicatorSource: "hlc3"},
+		{IndicatorName: "VWAP"},
+		{IndicatorName: "Data", IndicatorSource: "open"},
+	}
+	if len(indicators) != len(expected) {
+		t.Fatalf("Expected %d indicators; got: %d", len(expected), len(indicators))
+	}
+	for i := range expected {
+		if indicators[i] != expected[i] {
+			t.Errorf("Expected indicator %+v; got: %+v", expected[i], indicators[i])
+		}
+	}
+}
+
+func TestParseIndicatorsInvalid(t *testing.T) {
+	for _, value := range []string{"SMA:x", "SMA:-1", ":20", "SMA:20:close:1", "SMA,,RSI", "RSI:14:hcl3"} {
+		if _, err := parseIndicators(value); err == nil {
+			t.Errorf("parseIndicators should give error for %q but didn't get any", value)
+		}
+	}
+}
+
+func TestCalculateIndicatorSeries(t *testing.T) {
+	data := testCandles(5)
+
+	series, err := calculateIndicatorSeries(data, []models.Indicator{{IndicatorName: "SMA", IndicatorPeriod: 2}}, nil)
+	if err != nil {
+		t.Fatalf("calculateIndicatorSeries should not give error; got: %s", err.Error())
+	}
+	if sma := series["SMA(2)"]; len(sma) != 5 || sma[4] != 4.5 {
+		t.Errorf("Expected SMA(2) of 4.5 on the last candle; got: %v", sma)
+	}
+
+	_, err = calculateIndicatorSeries(data, []models.Indicator{{IndicatorName: "UNKNOWN", IndicatorPeriod: 3}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "UNKNOWN(3)") {
+		t.Errorf("Expected error naming the unknown indicator; got: %v", err)
+	}
+}
+
+func TestFilterDateRange(t *testing.T) {
+	// Candles of 2025-06-17 23:59, 2025-06-18 00:00 and 2025-06-19 00:00 UTC
+	day := time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC)
+	data := []models.IntradayData{
+		{Timestamp: day.Unix() - 60},
+		{Timestamp: day.Unix()},
+		{Timestamp: day.AddDate(0, 0, 1).Unix()},
+	}
+	series := map[string][]float64{"SMA(2)": {1, 2, 3}}
+
+	candles, filtered := filterDateRange(data, series, day, day.AddDate(0, 0, 1))
+	if len(candles) != 1 || candles[0].Timestamp != day.Unix() {
+		t.Errorf("Expected only the candle of 2025-06-18; got: %v", candles)
+	}
+	if len(filtered["SMA(2)"]) != 1 || filtered["SMA(2)"][0] != 2 {
+		t.Errorf("Expected series cut to the same candles; got: %v", filtered["SMA(2)"])
+	}
+
+	// Zero times don't limit the range
+	candles, _ = filterDateRange(data, series, time.Time{}, time.Time{})
+	if len(candles) != 3 {
+		t.Errorf("Expected all candles without a range; got: %d", len(candles))
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	data := testCandles(5)
+	series := map[string][]float64{"SMA(2)": {0, 1.5, 2.5, 3.5, 4.5}}
+
+	candles, downsampled := downsample(data, series, 2)
+
+	// Buckets of 3 candles, the last one only has 2
+	if len(candles) != 2 {
+		t.Fatalf("Expected 2 candles; got: %d", len(candles))
+	}
+	first := candles[0]
+	if first.Timestamp != marketOpen || first.Open != 1 || first.High != 4 || first.Low != 0 || first.Close != 3 || first.Volume != 300 {
+		t.Errorf("Expected first bucket merged from the first 3 candles; got: %+v", first)
+	}
+	if candles[1].Close != 5 || candles[1].Volume != 200 {
+		t.Errorf("Expected last bucket merged from the last 2 candles; got: %+v", candles[1])
+	}
+
+	sma := downsampled["SMA(2)"]
+	if len(sma) != 2 || sma[0] != 2.5 || sma[1] != 4.5 {
+		t.Errorf("Expected the last indicator value of each bucket; got: %v", sma)
+	}
+
+	// Nothing to merge when the data already fits
+	candles, _ = downsample(data, series, 10)
+	if len(candles) != 5 {
+		t.Errorf("Expected all candles when they fit maxPoints; got: %d", len(candles))
+	}
+}
+
+func TestWriteIndicatorCSV(t *testing.T) {
+	candles := testCandles(2)
+	candles[0].Datetime = "2025-06-18 13:30:00"
+	candles[1].Datetime = "2025-06-18 13:31:00"
+	indicators := []models.Indicator{{IndicatorName: "SMA", IndicatorPeriod: 2}, {IndicatorName: "RSI", IndicatorPeriod: 14}}
+	series := map[string][]float64{"SMA(2)": {0, 1.5}}
+
+	recorder := httptest.NewRecorder()
+	writeIndicatorCSV(recorder, candles, indicators, series)
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv" {
+		t.Errorf("Expected text/csv content type; got: %s", contentType)
+	}
+
+	// Indicators without a series get no column
+	expected := "timestamp,datetime,open,high,low,close,volume,SMA(2)\n" +
+		"1750253400,2025-06-18 13:30:00,1,2,0,1,100,0\n" +
+		"1750253460,2025-06-18 13:31:00,2,3,1,2,100,1.5\n"
+	if recorder.Body.String() != expected {
+		t.Errorf("Expected CSV:\n%s\ngot:\n%s", expected, recorder.Body.String())
+	}
+}
+
+// Data provider returning fixed candles and recording the requests it gets
+type recordingProvider struct {
+	data     []models.IntradayData
+	requests []marketdata.DataRequest
+}
+
+func (p *recordingProvider) GetIntradayData(request marketdata.DataRequest) ([]models.IntradayData, error) {
+	p.requests = append(p.requests, request)
+	return p.data, nil
+}
+
+func TestGetIndicatorSeriesFetchesRange(t *testing.T) {
+	provider := &recordingProvider{data: testCandles(30)}
+	handler := NewTrendHandler(nil, nil, provider, nil, nil, nil)
+
+	recorder := httptest.NewRecorder()
+	handler.GetIndicatorSeries(recorder, httptest.NewRequest("GET", "/indicators?symbol=AAPL&indicators=SMA:5&interval=1d&from=2025-06-18&to=2025-06-18", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200; got: %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	// The range is fetched with 10 trading days before it to warm up SMA(5)
+	request := provider.requests[0]
+	if !request.To.Equal(time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the range to end on 2025-06-19; got: %s", request.To)
+	}
+	if expected := time.Date(2025, 6, 3, 4, 0, 0, 0, time.UTC); !request.From.Equal(expected) {
+		t.Errorf("Expected the range to start at %s; got: %s", expected, request.From.UTC())
+	}
+}
+
+// Corporate action provider that always fails
+type failingCorporateActions struct{}
+
+func (failingCorporateActions) GetCorporateActions(symbol string, from, to time.Time) (marketdata.CorporateActions, error) {
+	return marketdata.CorporateActions{}, fmt.Errorf("%w: status code: 503", marketdata.ErrUpstreamDown)
+}
+
+func TestGetIndicatorSeriesChecksAndAdjustsData(t *testing.T) {
+	// The first test candle has a low of 0, which the quality check drops too
+	data := testCandles(11)[1:]
+	data[4].Close = -1
+	handler := NewTrendHandler(nil, nil, &recordingProvider{data: data}, failingCorporateActions{}, nil, nil)
+
+	recorder := httptest.NewRecorder()
+	handler.GetIndicatorSeries(recorder, httptest.NewRequest("GET", "/indicators?symbol=AAPL&indicators=SMA:2", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200; got: %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response IndicatorSeriesResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	// The invalid candle is dropped by the default quality policy
+	if len(response.Candles) != 9 {
+		t.Errorf("Expected 9 candles after the quality check; got: %d", len(response.Candles))
+	}
+	// Adjusted by default, but raw when the corporate actions can't be retrieved
+	if response.Prices != marketdata.PricesRaw || response.AdjustmentError == "" {
+		t.Errorf("Expected raw prices with the adjustment error; got: %s, %q", response.Prices, response.AdjustmentError)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.GetIndicatorSeries(recorder, httptest.NewRequest("GET", "/indicators?symbol=AAPL&prices=unadjusted", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid prices; got: %d", recorder.Code)
+	}
+}
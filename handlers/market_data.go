@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+	"trend-hencher-api/marketdata"
+	"trend-hencher-api/models"
+)
+
+// Choices of /checkMarket and /indicators about the candles they run on
+type dataOptions struct {
+	prices  string // Raw or adjusted for splits and dividends
+	quality marketdata.QualityConfig
+}
+
+// Candles ready for the indicators, with how they were checked and adjusted
+type marketData struct {
+	candles         []models.IntradayData
+	prices          string
+	quality         models.QualityReport
+	adjustmentError string // Why adjusted prices were asked for but the candles are raw
+}
+
+// Parses prices (raw or adjusted), quality and gaps. Prices are adjusted for splits and dividends by
+// default when corporate actions are available, bad candles are dropped and gaps kept by default.
+func (h *TrendHandler) parseDataOptions(query url.Values) (dataOptions, error) {
+	options := dataOptions{prices: query.Get("prices"), quality: marketdata.DefaultQualityConfig()}
+	switch {
+	case options.prices == "" && h.corporateActions != nil:
+		options.prices = marketdata.PricesAdjusted
+	case options.prices == "":
+		options.prices = marketdata.PricesRaw
+	case options.prices != marketdata.PricesRaw && options.prices != marketdata.PricesAdjusted:
+		return options, fmt.Errorf("Invalid prices, expected raw or adjusted")
+	case options.prices == marketdata.PricesAdjusted && h.corporateActions == nil:
+		return options, fmt.Errorf("Adjusted prices are not available")
+	}
+
+	if policy := query.Get("quality"); policy != "" {
+		options.quality.Invalid = policy
+	}
+	if policy := query.Get("gaps"); policy != "" {
+		options.quality.Gaps = policy
+	}
+	return options, options.quality.Validate()
+}
+
+// Fetches the candles, validates and repairs them and back-adjusts them for splits and dividends,
+// so /checkMarket and /indicators work on the same data. Rejected data gives an ErrDataQuality error
+// with the quality report. Without corporate actions the candles stay raw and adjustmentError says why.
+func (h *TrendHandler) loadMarketData(request marketdata.DataRequest, symbol marketdata.Symbol, options dataOptions) (marketData, error) {
+	market := marketData{prices: options.prices}
+
+	data, err := h.dataProvider.GetIntradayData(request)
+	if err != nil {
+		return market, fmt.Errorf("Failed to retrieve or parse data: %w", err)
+	}
+
+	interval := request.Interval
+	if interval == "" {
+		interval = marketdata.DefaultInterval
+	}
+	data, market.quality, err = marketdata.CheckQuality(data, symbol.Exchange, interval, options.quality)
+	if errors.Is(err, marketdata.ErrDataQuality) {
+		return market, err
+	}
+	if err != nil {
+		return market, fmt.Errorf("Failed to check data quality: %v", err)
+	}
+
+	// Back-adjusted so splits and dividends in the period don't look like crashes
+	if market.prices == marketdata.PricesAdjusted && len(data) > 0 {
+		adjusted, err := adjustPrices(h.corporateActions, data, symbol)
+		if err != nil {
+			log.Printf("Error adjusting prices, using raw prices; %v", err)
+			market.prices = marketdata.PricesRaw
+			market.adjustmentError = "Failed to retrieve corporate actions: " + err.Error()
+		} else {
+			data = adjusted
+		}
+	}
+
+	market.candles = data
+	return market, nil
+}
+
+// Fetches the splits and dividends in the period of the data and back-adjusts the candles for them
+func adjustPrices(corporateActions marketdata.CorporateActionProvider, data []models.IntradayData, symbol marketdata.Symbol) ([]models.IntradayData, error) {
+	actions, err := corporateActions.GetCorporateActions(symbol.String(), time.Unix(data[0].Timestamp, 0), time.Unix(data[len(data)-1].Timestamp, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := symbol.Exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	return marketdata.AdjustPrices(data, actions, location), nil
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+	"trend-hencher-api/utils"
+)
+
+// GetQuota returns the use of the daily EODHD API calls counted by this instance since it started
+func (h *TrendHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.quota == nil {
+		http.Error(w, "API calls are not counted", http.StatusNotImplemented)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, h.quota.Quota())
+}
@@ -1,119 +1,80 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"os"
-	"time"
-	"trend-hencher-api/models"
+	"strconv"
+	"strings"
+	"trend-hencher-api/marketdata"
 	"trend-hencher-api/utils"
 )
 
-// Fetch intraday data for a symbol chosen, either from API or testdata retrieved locally.
-func fetchIntradayData(stockSymbol string) ([]models.IntradayData, error) {
-	defer utils.MeasureTime(time.Now(), "fetchIntradayData")
-
-	environment := os.Getenv("ENVIRONMENT")
-
-	if environment == "local" {
-		return fetchFromLocalFile()
-	}
-
-	return fetchFromAPI(stockSymbol)
-}
-
-func fetchFromAPI(stockSymbol string) ([]models.IntradayData, error) {
-	apiToken := os.Getenv("EODHD_API_TOKEN")
-	if apiToken == "" {
-		return nil, fmt.Errorf("API token is not set")
-	}
-
-	url := fmt.Sprintf("https://eodhd.com/api/intraday/%s.US?interval=1m&api_token=%s&fmt=json", stockSymbol, apiToken)
+// Number of symbols returned by a search when no limit is given, and the most that can be asked for
+const (
+	defaultSymbolLimit = 20
+	maxSymbolLimit     = 100
+)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+// SearchSymbols finds symbols by ticker or name, on one exchange or on all exchanges whose symbol
+// list is already loaded, e.g. /symbols?query=equinor&exchange=OL&limit=10
+func (h *TrendHandler) SearchSymbols(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
-
-	req.Header.Set("User-Agent", "Go-http-client/1.1")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch intraday data: %v", err)
+	if h.symbols == nil {
+		http.Error(w, "Symbol search is not available", http.StatusNotImplemented)
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch data, status code: %d", resp.StatusCode)
+	query := r.URL.Query()
+	search := query.Get("query")
+	if strings.TrimSpace(search) == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
 	}
 
-	var intradayData []models.IntradayData
-	err = json.NewDecoder(resp.Body).Decode(&intradayData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return intradayData, nil
-}
-
-func fetchFromLocalFile() ([]models.IntradayData, error) {
-	filePath := "testdata/AAPL-18-06-25.json"
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read local file: %v", err)
+	exchangeCodes := []string{}
+	if code := strings.ToUpper(query.Get("exchange")); code != "" {
+		if _, exists := marketdata.LookupExchange(code); !exists {
+			http.Error(w, "Unknown exchange "+code, http.StatusBadRequest)
+			return
+		}
+		exchangeCodes = append(exchangeCodes, code)
 	}
 
-	var intradayData []models.IntradayData
-	err = json.Unmarshal(data, &intradayData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse local JSON file: %v", err)
+	limit := defaultSymbolLimit
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSymbolLimit {
+			http.Error(w, "Invalid limit, expected 1 to "+strconv.Itoa(maxSymbolLimit), http.StatusBadRequest)
+			return
+		}
 	}
 
-	// Set correct timezone for intraday data:
-	intradayData, err = filterIntradayData(intradayData)
+	symbols, err := h.symbols.Search(search, exchangeCodes, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to filter data %v", err)
+		log.Printf("Error searching symbols; %v", err)
+		http.Error(w, "Failed to search symbols: "+err.Error(), marketDataStatus(err))
+		return
 	}
 
-	return intradayData, nil
+	utils.WriteJSON(w, http.StatusOK, symbols)
 }
 
-func filterIntradayData(intradayData []models.IntradayData) ([]models.IntradayData, error) {
-	// Load the Oslo timezone
-	osloLocation, err := time.LoadLocation("Europe/Oslo")
-	if err != nil {
-		log.Fatalf("Failed to load Oslo timezone: %v", err)
+// Checks that the exchange lists the symbol. Only an unknown symbol fails, when the symbol list
+// can't be loaded the check is skipped and the data provider has the last word.
+func (h *TrendHandler) validateSymbol(symbol marketdata.Symbol) error {
+	if h.symbols == nil {
+		return nil
 	}
 
-	filteredData := []models.IntradayData{}
-
-	for _, data := range intradayData {
-		// Convert the timestamp to Eastern Time
-		easternTime := utils.ConvertToEasternTime(data.Timestamp, data.GmtOffset)
-
-		// Check if the time falls within the open market hours (9:30 AM - 4:00 PM ET)
-		if (easternTime.Hour() > openHourET || (easternTime.Hour() == openHourET && easternTime.Minute() >= openMinuteET)) &&
-			easternTime.Hour() < closeHourET {
-
-			// Convert the timestamp to Oslo time
-			osloTime := easternTime.In(osloLocation)
-
-			// Add the data to the filtered list, but adjust the timestamp and datetime for Oslo time
-			filteredData = append(filteredData, models.IntradayData{
-				Timestamp: osloTime.Unix(), // Convert back to Unix timestamp if needed
-				GmtOffset: 3600,            // Set Oslo GMT offset manually (+1 hour standard, +2 hours DST)
-				Datetime:  osloTime.Format("2006-01-02 15:04:05"),
-				Open:      data.Open,
-				High:      data.High,
-				Low:       data.Low,
-				Close:     data.Close,
-				Volume:    data.Volume,
-			})
-		}
+	_, err := h.symbols.Lookup(symbol)
+	if err != nil && !errors.Is(err, marketdata.ErrUnknownSymbol) {
+		log.Printf("Warning: could not validate symbol %s; %v", symbol, err)
+		return nil
 	}
-
-	return filteredData, nil
+	return err
 }
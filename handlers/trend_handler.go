@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"log"
-	"math"
 	"net/http"
 	"strconv"
 	"time"
+	"trend-hencher-api/marketdata"
+	"trend-hencher-api/metrics"
 	"trend-hencher-api/models"
+	"trend-hencher-api/repository"
 	"trend-hencher-api/services"
 	"trend-hencher-api/utils"
 
@@ -17,18 +22,20 @@ import (
 type TrendHandler struct {
 	trendService         *services.TrendService
 	bigQueryTrendService *services.BigQueryTrendService
+	dataProvider         marketdata.MarketDataProvider
+	corporateActions     marketdata.CorporateActionProvider // Nil when prices can't be adjusted
+	symbols              *marketdata.SymbolDirectory        // Nil when symbols aren't validated
+	quota                marketdata.QuotaTracker            // Nil when no API calls are counted
 }
 
-const (
-	openHourET   = 9  // Market opens at 9 AM ET
-	openMinuteET = 30 // Market opens at 9:30 AM ET
-	closeHourET  = 16 // Market closes at 4 PM ET
-)
-
-func NewTrendHandler(trendService *services.TrendService, bigQueryTrendService *services.BigQueryTrendService) *TrendHandler {
+func NewTrendHandler(trendService *services.TrendService, bigQueryTrendService *services.BigQueryTrendService, dataProvider marketdata.MarketDataProvider, corporateActions marketdata.CorporateActionProvider, symbols *marketdata.SymbolDirectory, quota marketdata.QuotaTracker) *TrendHandler {
 	return &TrendHandler{
 		trendService:         trendService,
 		bigQueryTrendService: bigQueryTrendService,
+		dataProvider:         dataProvider,
+		corporateActions:     corporateActions,
+		symbols:              symbols,
+		quota:                quota,
 	}
 }
 
@@ -125,6 +132,44 @@ func (h *TrendHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, transactions)
 }
 
+// GetReport returns the full performance report of a trend saved by /checkMarket
+func (h *TrendHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trendID := r.URL.Query().Get("id")
+	if trendID == "" {
+		http.Error(w, "Missing trend ID", http.StatusBadRequest)
+		return
+	}
+
+	trend, err := h.bigQueryTrendService.GetTrend(trendID)
+	if errors.Is(err, repository.ErrTrendNotFound) {
+		http.Error(w, "Trend not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve trend", http.StatusInternalServerError)
+		return
+	}
+
+	transactions, err := h.bigQueryTrendService.GetTransactions(trendID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve transactions", http.StatusInternalServerError)
+		return
+	}
+
+	// Annualize over the trading days of the exchange like /checkMarket did
+	years := 0.0
+	if symbol, err := marketdata.ParseSymbol(trend.Stock); err == nil {
+		years = symbol.Exchange.TradingYears(trend.PeriodStart, trend.PeriodEnd)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, metrics.BuildReport(trendID, transactions, trend.PeriodStart, trend.PeriodEnd, years))
+}
+
 func (h *TrendHandler) CheckMarket(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -136,107 +181,323 @@ func (h *TrendHandler) CheckMarket(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing stock symbol", http.StatusBadRequest)
 		return
 	}
+	symbol, err := marketdata.ParseSymbol(stockSymbol)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.validateSymbol(symbol); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Scoring model for all scenarios, otherwise each scenario's own or the default
+	scorerName := r.URL.Query().Get("scorer")
+	if scorerName != "" {
+		if _, err := metrics.GetScorer(scorerName); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Bar interval of the data, 1 minute candles by default
+	interval := r.URL.Query().Get("interval")
+	if err := marketdata.ValidateInterval(interval); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if interval == "" {
+		interval = marketdata.DefaultInterval
+	}
+
+	// Optional date range to backtest, otherwise the default window of the data provider
+	from, to, err := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.parseDataOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Fetch data from API:
 	log.Println("Checking market...")
-	intradayData, err := fetchIntradayData(stockSymbol)
+	market, err := h.loadMarketData(marketdata.DataRequest{Symbol: stockSymbol, Interval: interval, From: from, To: to}, symbol, options)
+	if errors.Is(err, marketdata.ErrDataQuality) {
+		log.Printf("Rejected data of %s; %v", stockSymbol, err)
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, models.CheckMarketResponse{Symbol: stockSymbol, Interval: interval, Prices: market.prices, Quality: market.quality, Results: []models.ScenarioResult{}})
+		return
+	}
 	if err != nil {
-		log.Printf("Error fetching data; %v", err)
-		http.Error(w, "Failed to retrieve or parse data", http.StatusUnauthorized)
+		log.Printf("Error loading data; %v", err)
+		http.Error(w, err.Error(), marketDataStatus(err))
 		return
 	}
 
 	// Run trends:
-	err = createTrends(h, intradayData, stockSymbol)
+	results, err := createTrends(h, market.candles, stockSymbol, interval, market.prices, scorerName)
 	if err != nil {
+		log.Printf("Error creating trends; %v", err)
 		http.Error(w, "Failed creating trends", http.StatusInternalServerError)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, "Created trends")
+	utils.WriteJSON(w, http.StatusCreated, models.CheckMarketResponse{
+		Symbol:          stockSymbol,
+		Interval:        interval,
+		Prices:          market.prices,
+		AdjustmentError: market.adjustmentError,
+		Quality:         market.quality,
+		Results:         results,
+	})
+}
+
+// HTTP status for an error of the market data provider
+func marketDataStatus(err error) int {
+	switch {
+	case errors.Is(err, marketdata.ErrUnknownSymbol):
+		return http.StatusNotFound
+	case errors.Is(err, marketdata.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, marketdata.ErrUpstreamDown):
+		return http.StatusServiceUnavailable
+	}
+	// Rejected API token (ours, not the caller's) or an unexpected response of the provider
+	return http.StatusBadGateway
+}
+
+// Outcome of running one scenario, kept until all scenarios are done so their significance can be corrected together
+type scenarioResult struct {
+	scenario     models.ScenarioConfig
+	trendID      string
+	transactions []models.Transaction
+	scorer       metrics.Scorer
+	trendScore   float64
+	performance  metrics.Metrics
+	significance metrics.Significance
 }
 
-func createTrends(h *TrendHandler, data []models.IntradayData, symbol string) error {
+// Seed of the bootstrap, based on the scenario name so results are repeatable
+func scenarioSeed(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
+
+// Runs all scenarios on the data, saves a trend with its transactions for each and returns their results.
+// It only fails when none of the trends could be saved.
+func createTrends(h *TrendHandler, data []models.IntradayData, symbol string, interval string, prices string, scorerName string) ([]models.ScenarioResult, error) {
 	// Get all predefined scenarios
 	scenarios := models.GetPredefinedScenarios()
 
+	parsedSymbol, err := marketdata.ParseSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	exchange := parsedSymbol.Exchange
+
+	sessionStarts, err := marketdata.SessionStarts(data, exchange)
+	if err != nil {
+		return nil, err
+	}
+	sessionEnds, err := marketdata.SessionEnds(data, exchange, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Period of the data, used to annualize the metrics over the trading days of the exchange
+	var start, end time.Time
+	years := 0.0
+	if len(data) > 0 {
+		start, _ = metrics.ParseDate(data[0].Datetime)
+		end, _ = metrics.ParseDate(data[len(data)-1].Datetime)
+		years = exchange.TradingYears(time.Unix(data[0].Timestamp, 0), time.Unix(data[len(data)-1].Timestamp, 0))
+	}
+
 	// Run through each scenario
+	results := []scenarioResult{}
 	for _, scenario := range scenarios {
+		// Scenarios made for another bar interval, like daily swing trading, are skipped
+		if !scenario.RunsOnInterval(interval) {
+			continue
+		}
+
 		trendID := uuid.New().String()
 
-		transactions, err := createTransactions(data, scenario.IndicatorBuyScenario, scenario.IndicatorSellScenario, trendID)
+		transactions, err := createTransactions(data, sessionStarts, sessionEnds, scenario.IndicatorBuyScenario, scenario.IndicatorSellScenario, scenario.CustomIndicators, trendID)
+		if err != nil {
+			log.Printf("transaction error for scenario %s: %v", scenario.Name, err)
+			continue // Skip this scenario if there's an error
+		}
 
-		trendScore := calculateTrendScore(transactions)
+		scenarioScorer := scorerName
+		if scenarioScorer == "" {
+			scenarioScorer = scenario.Scorer
+		}
+		scorer, err := metrics.GetScorer(scenarioScorer)
 		if err != nil {
 			log.Printf("scoring error for scenario %s: %v", scenario.Name, err)
 			continue // Skip this scenario if there's an error
 		}
 
-		log.Println("score for scenario: ", trendScore)
-		/*
-			trend := models.Trend{
-				TrendID:               trendID,
-				Stock:                 symbol,
-				TrendScore:            trendScore,
-				YearlyProfit:			yearlyProfit,
-				Date:                  time.Now(),
-				IndicatorBuyScenario:  scenario.IndicatorBuyScenario,
-				IndicatorSellScenario: scenario.IndicatorSellScenario,
-			}
+		performance := metrics.CalculateOverYears(transactions, start, end, years)
+		results = append(results, scenarioResult{
+			scenario:     scenario,
+			trendID:      trendID,
+			transactions: transactions,
+			scorer:       scorer,
+			trendScore:   scorer.Score(performance),
+			performance:  performance,
+			significance: metrics.TestSignificance(transactions, scenarioSeed(scenario.Name)),
+		})
+	}
 
-			// Save Trend
-			if err := h.bigQueryTrendService.SaveTrend(&trend); err != nil {
-				log.Printf("error saving trend for scenario %s: %v", scenario.Name, err)
-				continue
-			}
+	// Many scenarios are tested on the same data, so correct for some looking good by chance
+	significance := make([]metrics.Significance, len(results))
+	for i, result := range results {
+		significance[i] = result.significance
+	}
+	metrics.AdjustSignificance(significance)
+
+	response := []models.ScenarioResult{}
+	saved := 0
+	for i, result := range results {
+		scenario := result.scenario
+		result.significance = significance[i]
+
+		log.Printf("score for scenario (%s): %.3f, p-value: %.4f, significant: %t", result.scorer.Name(), result.trendScore, result.significance.AdjustedPValue, result.significance.Significant)
+
+		trend := models.Trend{
+			TrendID:               result.trendID,
+			Stock:                 symbol,
+			ScenarioName:          scenario.Name,
+			Interval:              interval,
+			Prices:                prices,
+			TrendScore:            result.trendScore,
+			ScoringProfile:        result.scorer.Name(),
+			PValue:                result.significance.AdjustedPValue,
+			Significant:           result.significance.Significant,
+			YearlyProfit:          result.performance.AnnualizedReturn,
+			Date:                  time.Now(),
+			PeriodStart:           start,
+			PeriodEnd:             end,
+			IndicatorBuyScenario:  scenario.IndicatorBuyScenario,
+			IndicatorSellScenario: scenario.IndicatorSellScenario,
+			CustomIndicators:      scenario.CustomIndicators,
+		}
 
-			// Generate transactionIDs
-			for i := range transactions {
-				transactions[i].TransactionID = uuid.New().String()
-			}
+		scenarioResponse := models.ScenarioResult{
+			TrendID:        trend.TrendID,
+			ScenarioName:   scenario.Name,
+			Interval:       interval,
+			TrendScore:     trend.TrendScore,
+			ScoringProfile: trend.ScoringProfile,
+			YearlyProfit:   trend.YearlyProfit,
+			PValue:         trend.PValue,
+			Significant:    trend.Significant,
+			Transactions:   len(result.transactions),
+		}
+
+		if err := saveTrend(h, &trend, result.transactions); err != nil {
+			log.Printf("error saving scenario %s: %v", scenario.Name, err)
+			scenarioResponse.Error = err.Error()
+			response = append(response, scenarioResponse)
+			continue
+		}
 
-			// Save transactions
-			if err := h.bigQueryTrendService.SaveTransactions(transactions); err != nil {
-				log.Printf("error saving transactions for scenario %s: %v", scenario.Name, err)
-				continue
-			} */
+		scenarioResponse.Saved = true
+		response = append(response, scenarioResponse)
+		saved++
 
 		log.Printf("Successfully processed scenario: %s", scenario.Name)
 	}
 
+	if len(results) > 0 && saved == 0 {
+		return response, fmt.Errorf("none of the %d trends could be saved", len(results))
+	}
+
+	return response, nil
+}
+
+// Saves the trend and its transactions
+func saveTrend(h *TrendHandler, trend *models.Trend, transactions []models.Transaction) error {
+	if err := h.bigQueryTrendService.SaveTrend(trend); err != nil {
+		return fmt.Errorf("saving trend: %v", err)
+	}
+
+	// Nothing to save when the scenario never completed a trade
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	// Generate transactionIDs
+	for i := range transactions {
+		transactions[i].TransactionID = uuid.New().String()
+		transactions[i].TrendID = trend.TrendID
+	}
+
+	if err := h.bigQueryTrendService.SaveTransactions(transactions); err != nil {
+		return fmt.Errorf("saving transactions: %v", err)
+	}
+
 	return nil
 }
 
-func createTransactions(data []models.IntradayData, buyScenario models.BuyScenario, sellScenario models.SellScenario, trendID string) ([]models.Transaction, error) {
+// sessionStarts marks the bars that start a trading session, used to reset the VWAP, and
+// sessionEnds the bars that end one, used by end of session exits
+func createTransactions(data []models.IntradayData, sessionStarts []bool, sessionEnds []bool, buyScenario models.BuyScenario, sellScenario models.SellScenario, customIndicators []models.CustomIndicator, trendID string) ([]models.Transaction, error) {
 	transactions := []models.Transaction{}
 	inPosition := false
 	var lastBuy models.Transaction
+	var buyIndex int
 
-	indicatorCache := models.GetPredefinedIndicators(buyScenario, sellScenario, data)
+	// A trade needs a candle to buy on after the first and one to sell on after that
+	if len(data) < 3 {
+		return transactions, nil
+	}
+
+	indicatorCache, err := models.GetPredefinedIndicators(buyScenario, sellScenario, customIndicators, data, sessionStarts)
+	if err != nil {
+		return nil, err
+	}
+	exitsAtSessionEnd := sellScenario.ExitsAtSessionEnd()
 
 	// Get transactions:
 	for i := 1; i < len(data); i++ {
 		price := data[i].Close
 
 		// Check for BuyScenario
-		if !inPosition {
+		// No new positions at the end of the session when they have to be closed by then
+		if !inPosition && !(exitsAtSessionEnd && sessionEnds[i]) {
 			if shouldBuy(buyScenario, i, indicatorCache) {
 				lastBuy = models.Transaction{
-					DateBought:  data[i].Datetime,
-					PriceBought: price,
-					Volume:      int64(1000000 / price), // Assuming total invested per trade is 1.000.000~
+					DateBought:   data[i].Datetime,
+					PriceBought:  price,
+					Volume:       int64(1000000 / price), // Assuming total invested per trade is 1.000.000~
+					HighestPrice: price,
+					LowestPrice:  price,
 				}
+				buyIndex = i
+				transactions = append(transactions, lastBuy)
+				inPosition = true
 			}
-			transactions = append(transactions, lastBuy)
-			inPosition = true
 		}
 
 		// Check for SellScenario
 		if inPosition {
-			if shouldSell(sellScenario, lastBuy.PriceBought, price, i, indicatorCache) {
+			// Bought at the close, so only the candles after the buy are part of the price path
+			if i > buyIndex {
+				lastBuy.RecordPrice(data[i].High, data[i].Low)
+			}
+
+			if shouldSell(sellScenario, lastBuy.PriceBought, price, buyIndex, i, sessionEnds[i], indicatorCache) {
 				lastBuy.DateSold = data[i].Datetime
 				lastBuy.PriceSold = price
 				lastBuy.TrendID = trendID
+				lastBuy.RecordExcursions(i - buyIndex)
 				transactions[len(transactions)-1] = lastBuy
 				inPosition = false
 			}
@@ -255,16 +516,9 @@ func createTransactions(data []models.IntradayData, buyScenario models.BuyScenar
 func shouldBuy(buyScenario models.BuyScenario, index int, indicatorCache map[models.IndicatorKey][]float64) bool {
 	for _, cond := range buyScenario.Conditions {
 
-		indicatorSourceData := indicatorCache[models.IndicatorKey{Name: cond.IndicatorName, Period: cond.IndicatorPeriod}]
+		indicatorSourceData := indicatorCache[models.ConditionKey(cond)]
 
-		// if source is checking against specific value we don't need cache(Used by RSI/WILLR etc.)
-		var indicatorTargetData []float64
-		if cond.IndicatorCheckValue.IndicatorName == "Data" || cond.IndicatorCheckValue.IndicatorPeriod > 0 {
-			indicatorTargetData = indicatorCache[models.IndicatorKey{
-				Name:   cond.IndicatorCheckValue.IndicatorName,
-				Period: cond.IndicatorCheckValue.IndicatorPeriod,
-			}]
-		}
+		indicatorTargetData := checkValueSeries(cond, indicatorCache)
 
 		if !checkCondition(indicatorSourceData, indicatorTargetData, cond, index) {
 			return false
@@ -273,23 +527,45 @@ func shouldBuy(buyScenario models.BuyScenario, index int, indicatorCache map[mod
 	return true
 }
 
-func shouldSell(sellScenario models.SellScenario, buyPrice, currentPrice float64, index int, indicatorCache map[models.IndicatorKey][]float64) bool {
+func shouldSell(sellScenario models.SellScenario, buyPrice, currentPrice float64, buyIndex, index int, sessionEnd bool, indicatorCache map[models.IndicatorKey][]float64) bool {
+	// End of session exits close the position regardless of the other conditions
+	if sellScenario.ExitsAtSessionEnd() && sessionEnd && index > buyIndex {
+		return true
+	}
+
+	conditions := 0
 	for _, sellCondition := range sellScenario.Conditions {
+		if sellCondition.ConditionType != models.SellEndOfSession {
+			conditions++
+		}
+
 		switch sellCondition.ConditionType {
 		case models.SellPercentage:
-			if !(currentPrice > buyPrice*(sellCondition.ProfitThreshold/100) || currentPrice < buyPrice*(sellCondition.LossThreshold/100)) {
+			if !(currentPrice > buyPrice*sellCondition.ProfitThreshold || currentPrice < buyPrice*sellCondition.LossThreshold) {
 				return false
 			}
-		case models.SellIndicator:
-			indicatorSourceData := indicatorCache[models.IndicatorKey{Name: sellCondition.IndicatorName, Period: sellCondition.IndicatorPeriod}]
-
-			var indicatorTargetData []float64
-			if sellCondition.IndicatorCheckValue.IndicatorName == "Data" || sellCondition.IndicatorCheckValue.IndicatorPeriod > 0 {
-				indicatorTargetData = indicatorCache[models.IndicatorKey{
-					Name:   sellCondition.IndicatorCheckValue.IndicatorName,
-					Period: sellCondition.IndicatorCheckValue.IndicatorPeriod,
-				}]
+		case models.SellATR:
+			atr := indicatorCache[models.IndicatorKey{Name: "ATR", Period: sellCondition.IndicatorPeriod}]
+
+			// Stop and target are fixed by the ATR at entry, use the latest ATR if it was still warming up.
+			// Without any ATR yet the stop would be at the buy price, so the position is held.
+			entryATR := atr[buyIndex]
+			if entryATR <= 0 {
+				entryATR = atr[index]
 			}
+			if entryATR <= 0 {
+				return false
+			}
+
+			target := buyPrice + sellCondition.ProfitThreshold*entryATR
+			stop := buyPrice - sellCondition.LossThreshold*entryATR
+			if !(currentPrice >= target || currentPrice <= stop) {
+				return false
+			}
+		case models.SellIndicator:
+			indicatorSourceData := indicatorCache[models.ConditionKey(sellCondition)]
+
+			indicatorTargetData := checkValueSeries(sellCondition, indicatorCache)
 
 			if !checkCondition(indicatorSourceData, indicatorTargetData, sellCondition, index) {
 				return false
@@ -297,7 +573,19 @@ func shouldSell(sellScenario models.SellScenario, buyPrice, currentPrice float64
 		}
 	}
 
-	return true
+	// A scenario that only exits at the end of the session holds until then
+	return conditions > 0 || !sellScenario.ExitsAtSessionEnd()
+}
+
+// if source is checking against specific value we don't need cache(Used by RSI/WILLR etc.)
+// A check value without period named like the condition indicator is such a value, any other
+// check value refers to a series which is nil when it isn't in the cache.
+func checkValueSeries(condition models.IndicatorCondition, indicatorCache map[models.IndicatorKey][]float64) []float64 {
+	checkValue := condition.GetCheckValue()
+	if !checkValue.UsesSeries() && checkValue.IndicatorName == condition.GetIndicatorName() {
+		return nil
+	}
+	return indicatorCache[checkValue.Key()]
 }
 
 func checkCondition(sourceData []float64, targetData []float64, condition models.IndicatorCondition, index int) bool {
@@ -334,72 +622,11 @@ func checkCondition(sourceData []float64, targetData []float64, condition models
 			prevTarget = currTarget
 		}
 		return prevSource > prevTarget && currSource <= currTarget
+	case models.IndicatorPatternBullish:
+		return currSource > 0
+	case models.IndicatorPatternBearish:
+		return currSource < 0
 	default:
 		return false
 	}
 }
-
-// Fake normalizations are being done - meaning any trend can have a score above 1
-// but most won't. When they go above 1 they are most likely very good trends!
-func calculateTrendScore(transactions []models.Transaction) float64 {
-
-	// Occurrence (assuming a max of 100)
-	normalizedOccurrence := len(transactions) / 100
-	occurrenceWeight := 0.15
-
-	// Profitability (Assuming a max of 100%, and total invested: 1.000.000~ per trade)
-	totalProfit := 0.0
-	for _, transaction := range transactions {
-		profit := (transaction.PriceSold - transaction.PriceBought) * float64(transaction.Volume)
-		totalProfit += profit
-	}
-	log.Printf("totalProfit: %.2f", totalProfit)
-	normalizedProfitability := totalProfit / 1000000
-	profitabilityWeight := 0.45
-
-	// Consistency
-	winningTransactions := 0
-	totalTransactions := len(transactions)
-	normalizedConsistency := 0.0
-
-	for _, transaction := range transactions {
-		if transaction.PriceSold > transaction.PriceBought {
-			winningTransactions++
-		}
-	}
-
-	if totalTransactions != 0 {
-		normalizedConsistency = float64(winningTransactions) / float64(totalTransactions)
-	}
-	consistencyWeight := 0.25
-
-	// Variance
-	normalizedVariance := calculateVarianceScore(transactions)
-	varianceWeight := 0.15
-
-	trendScore := occurrenceWeight*float64(normalizedOccurrence) + profitabilityWeight*normalizedProfitability + consistencyWeight*normalizedConsistency + varianceWeight*normalizedVariance
-	return math.Round(trendScore*1000) / 1000
-}
-
-func calculateVarianceScore(transactions []models.Transaction) float64 {
-	var percentageProfits []float64
-
-	// Calculate percentage profit for each transaction
-	for _, transaction := range transactions {
-		percentageProfit := ((transaction.PriceSold - transaction.PriceBought) / transaction.PriceBought) * 100
-		percentageProfits = append(percentageProfits, percentageProfit)
-	}
-
-	averageProfit := utils.CalculateAverage(percentageProfits)
-	medianProfit := utils.CalculateMedian(percentageProfits)
-
-	// Calculate the variance (difference between average and median percentage profit)
-	variance := math.Abs(averageProfit - medianProfit)
-
-	maxVariance := 1.0
-	varianceScore := 1 - (variance / maxVariance)
-	if varianceScore < 0 {
-		varianceScore = 0 // Ensure the score doesn't go below 0
-	}
-	return varianceScore
-}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"trend-hencher-api/models"
+)
+
+func TestShouldSell(t *testing.T) {
+	atrKey := models.IndicatorKey{Name: "ATR", Period: 14}
+	atrCache := map[models.IndicatorKey][]float64{
+		// ATR is still warming up at index 0 and 1, 2 at the entry on index 2 and 4 afterwards
+		atrKey: {0, 0, 2, 4, 4},
+	}
+	atrExit := models.SellScenario{Conditions: []models.SellCondition{
+		{ConditionType: models.SellATR, ProfitThreshold: 2, LossThreshold: 1, IndicatorPeriod: 14},
+	}}
+	percentageExit := models.SellScenario{Conditions: []models.SellCondition{
+		{ConditionType: models.SellPercentage, ProfitThreshold: 1.05, LossThreshold: 0.98},
+	}}
+
+	tests := []struct {
+		name         string
+		sellScenario models.SellScenario
+		currentPrice float64
+		buyIndex     int
+		index        int
+		cache        map[models.IndicatorKey][]float64
+		expected     bool
+	}{
+		{"percentage profit", percentageExit, 106, 1, 2, nil, true},
+		{"percentage loss", percentageExit, 97, 1, 2, nil, true},
+		{"percentage hold", percentageExit, 101, 1, 2, nil, false},
+		// Target and stop come from the ATR at entry (2), not the wider ATR of the current bar (4)
+		{"ATR target", atrExit, 104, 2, 4, atrCache, true},
+		{"ATR stop", atrExit, 98, 2, 4, atrCache, true},
+		{"ATR hold", atrExit, 103, 2, 4, atrCache, false},
+		// Without an ATR at entry the current ATR is used, so the target is 100 + 2*4
+		{"ATR warming up hold", atrExit, 104, 0, 3, atrCache, false},
+		{"ATR warming up target", atrExit, 108, 0, 3, atrCache, true},
+		// Without any ATR the stop would be the buy price, so nothing is sold
+		{"ATR not warmed up", atrExit, 99, 0, 1, atrCache, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sell := shouldSell(test.sellScenario, 100, test.currentPrice, test.buyIndex, test.index, false, test.cache)
+			if sell != test.expected {
+				t.Errorf("Expected shouldSell %t; got: %t", test.expected, sell)
+			}
+		})
+	}
+}
+
+// Buys when the close is above 100
+var closeAbove100 = models.BuyScenario{Conditions: []models.BuyCondition{
+	{IndicatorName: "Data", IndicatorType: models.IndicatorOver, IndicatorCheckValue: models.Indicator{IndicatorStrength: 100}},
+}}
+
+func TestCreateTransactionsRecordsExcursions(t *testing.T) {
+	data := []models.IntradayData{
+		{Datetime: "2025-06-18 13:30:00", High: 100, Low: 98, Close: 99},
+		// Bought at the close, so the range of this candle isn't part of the price path
+		{Datetime: "2025-06-18 13:31:00", High: 120, Low: 80, Close: 101},
+		{Datetime: "2025-06-18 13:32:00", High: 104, Low: 100, Close: 102},
+		{Datetime: "2025-06-18 13:33:00", High: 99, Low: 96, Close: 98},
+		{Datetime: "2025-06-18 13:34:00", High: 107, Low: 105, Close: 106},
+	}
+	sellScenario := models.SellScenario{Conditions: []models.SellCondition{
+		{ConditionType: models.SellPercentage, ProfitThreshold: 1.04, LossThreshold: 0.9},
+	}}
+
+	transactions, err := createTransactions(data, nil, make([]bool, len(data)), closeAbove100, sellScenario, nil, "trend")
+	if err != nil {
+		t.Fatalf("createTransactions should not give error; got: %s", err.Error())
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1 transaction; got: %d", len(transactions))
+	}
+
+	transaction := transactions[0]
+	if transaction.DateBought != "2025-06-18 13:31:00" || transaction.DateSold != "2025-06-18 13:34:00" {
+		t.Errorf("Expected bought at 13:31 and sold at 13:34; got: %s and %s", transaction.DateBought, transaction.DateSold)
+	}
+	if transaction.HighestPrice != 107 || transaction.LowestPrice != 96 {
+		t.Errorf("Expected highest price 107 and lowest 96; got: %.2f and %.2f", transaction.HighestPrice, transaction.LowestPrice)
+	}
+	if transaction.BarsHeld != 3 {
+		t.Errorf("Expected 3 bars held; got: %d", transaction.BarsHeld)
+	}
+
+	expected := map[string][2]float64{
+		"max adverse excursion":   {transaction.MaxAdverseExcursion, 5.0 / 101},
+		"max favorable excursion": {transaction.MaxFavorableExcursion, 6.0 / 101},
+		"entry efficiency":        {transaction.EntryEfficiency, 6.0 / 11},
+		"exit efficiency":         {transaction.ExitEfficiency, 10.0 / 11},
+	}
+	for name, values := range expected {
+		if math.Abs(values[0]-values[1]) > 1e-9 {
+			t.Errorf("Expected %s %.4f; got: %.4f", name, values[1], values[0])
+		}
+	}
+}
+
+func TestCreateTransactionsDropsOpenPosition(t *testing.T) {
+	data := []models.IntradayData{{Close: 99}, {Close: 101}, {Close: 102}}
+	sellScenario := models.SellScenario{Conditions: []models.SellCondition{
+		{ConditionType: models.SellPercentage, ProfitThreshold: 1.1, LossThreshold: 0.9},
+	}}
+
+	transactions, _ := createTransactions(data, nil, make([]bool, len(data)), closeAbove100, sellScenario, nil, "trend")
+	if len(transactions) != 0 {
+		t.Errorf("Expected the position that was never sold to be dropped; got: %d transactions", len(transactions))
+	}
+}
+
+func TestCreateTransactionsAtSessionEnd(t *testing.T) {
+	endOfSession := models.SellCondition{ConditionType: models.SellEndOfSession}
+	wideTargets := models.SellCondition{ConditionType: models.SellPercentage, ProfitThreshold: 1.5, LossThreshold: 0.5}
+
+	tests := []struct {
+		name         string
+		closes       []float64
+		sessionEnds  []bool
+		sellScenario models.SellScenario
+		expected     [][2]int // Indexes bought and sold
+	}{
+		{
+			name:         "only end of session holds until the close",
+			closes:       []float64{99, 101, 102, 103, 104, 105},
+			sessionEnds:  []bool{false, false, false, true, false, true},
+			sellScenario: models.SellScenario{Conditions: []models.SellCondition{endOfSession}},
+			expected:     [][2]int{{1, 3}, {4, 5}},
+		},
+		{
+			name:         "no entry on the last bar of the session",
+			closes:       []float64{99, 101, 102, 103},
+			sessionEnds:  []bool{false, true, false, true},
+			sellScenario: models.SellScenario{Conditions: []models.SellCondition{endOfSession}},
+			expected:     [][2]int{{2, 3}},
+		},
+		{
+			name:         "forced exit before the other conditions are met",
+			closes:       []float64{99, 101, 102, 103, 104},
+			sessionEnds:  []bool{false, false, true, false, false},
+			sellScenario: models.SellScenario{Conditions: []models.SellCondition{wideTargets, endOfSession}},
+			expected:     [][2]int{{1, 2}},
+		},
+		{
+			name:         "held over the session end without an end of session exit",
+			closes:       []float64{99, 101, 102, 160},
+			sessionEnds:  []bool{false, true, true, false},
+			sellScenario: models.SellScenario{Conditions: []models.SellCondition{wideTargets}},
+			expected:     [][2]int{{1, 3}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := make([]models.IntradayData, len(test.closes))
+			for i, price := range test.closes {
+				data[i] = models.IntradayData{Datetime: strconv.Itoa(i), High: price, Low: price, Close: price}
+			}
+
+			transactions, err := createTransactions(data, nil, test.sessionEnds, closeAbove100, test.sellScenario, nil, "trend")
+			if err != nil {
+				t.Fatalf("createTransactions should not give error; got: %s", err.Error())
+			}
+			if len(transactions) != len(test.expected) {
+				t.Fatalf("Expected %d transactions; got: %+v", len(test.expected), transactions)
+			}
+			for i, expected := range test.expected {
+				bought, sold := strconv.Itoa(expected[0]), strconv.Itoa(expected[1])
+				if transactions[i].DateBought != bought || transactions[i].DateSold != sold {
+					t.Errorf("Expected transaction bought at %s and sold at %s; got: %s and %s", bought, sold, transactions[i].DateBought, transactions[i].DateSold)
+				}
+			}
+		})
+	}
+}
+
+func TestShouldSellAtSessionEnd(t *testing.T) {
+	sellScenario := models.SellScenario{Conditions: []models.SellCondition{{ConditionType: models.SellEndOfSession}}}
+
+	if !shouldSell(sellScenario, 100, 100, 1, 2, true, nil) {
+		t.Error("Expected a sell at the end of the session")
+	}
+	if shouldSell(sellScenario, 100, 100, 1, 2, false, nil) {
+		t.Error("Expected to hold within the session when only exiting at the end of it")
+	}
+	if shouldSell(sellScenario, 100, 100, 2, 2, true, nil) {
+		t.Error("Expected no sell on the bar the position was bought")
+	}
+}
+
+func TestCreateTransactionsUnknownIndicator(t *testing.T) {
+	data := testCandles(5)
+	buyScenario := models.BuyScenario{Conditions: []models.BuyCondition{
+		{IndicatorName: "Data", IndicatorType: models.IndicatorOver, IndicatorCheckValue: models.Indicator{IndicatorName: "missing"}},
+	}}
+
+	_, err := createTransactions(data, make([]bool, len(data)), make([]bool, len(data)), buyScenario, models.SellScenario{}, nil, "trend")
+	if err == nil {
+		t.Errorf("createTransactions should give error for a missing series but didn't get any")
+	}
+}
+
+func TestCreateTransactionsWithFewerBarsThanThePeriod(t *testing.T) {
+	buyScenario := models.BuyScenario{Conditions: []models.BuyCondition{
+		// The warm-up series is zero, so it's never over the close
+		{IndicatorName: "SMA", IndicatorType: models.IndicatorOver, IndicatorPeriod: 200, IndicatorCheckValue: models.Indicator{IndicatorName: "Data"}},
+	}}
+	sellScenario := models.SellScenario{Conditions: []models.SellCondition{
+		{ConditionType: models.SellATR, ProfitThreshold: 2, LossThreshold: 1, IndicatorPeriod: 14},
+	}}
+
+	for _, count := range []int{0, 1, 5, 50} {
+		data := testCandles(count)
+		transactions, err := createTransactions(data, nil, make([]bool, len(data)), buyScenario, sellScenario, nil, "trend")
+		if err != nil || len(transactions) != 0 {
+			t.Errorf("Expected no trades while SMA(200) warms up on %d candles; got: %d, %v", count, len(transactions), err)
+		}
+	}
+}
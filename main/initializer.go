@@ -4,12 +4,15 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
 
 	"cloud.google.com/go/bigquery"
 	"cloud.google.com/go/datastore"
 	"github.com/joho/godotenv"
 
 	"trend-hencher-api/handlers"
+	"trend-hencher-api/marketdata"
+	"trend-hencher-api/metrics"
 	"trend-hencher-api/repository"
 	"trend-hencher-api/services"
 )
@@ -22,6 +25,22 @@ func initEnv() {
 	}
 }
 
+// initScoringProfiles registers the scoring profiles next to the built-in scorers, from the file in
+// SCORING_PROFILES when it is set and otherwise the scoring_profiles.json embedded in the binary
+func initScoringProfiles() {
+	var profiles []metrics.ScoringProfile
+	var err error
+	if path := os.Getenv("SCORING_PROFILES"); path != "" {
+		profiles, err = metrics.LoadScoringProfiles(path)
+	} else {
+		profiles, err = metrics.LoadDefaultScoringProfiles()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load scoring profiles: %v", err)
+	}
+	log.Printf("Loaded %d scoring profiles", len(profiles))
+}
+
 // initHandler initializes the necessary services and returns a TrendHandler
 func initTrendHandler() *handlers.TrendHandler {
 	// Create the context
@@ -47,6 +66,132 @@ func initTrendHandler() *handlers.TrendHandler {
 	bigQueryService := services.NewBigQueryService(bigqueryRepo)
 	trendService := services.NewTrendService(datastorerepo)
 
+	// One EODHD client for candles and corporate actions, so they share the rate limit and daily quota
+	eodhd := initEODHDProvider()
+
 	// Return the initialized TrendHandler
-	return handlers.NewTrendHandler(trendService, bigQueryService)
+	return handlers.NewTrendHandler(trendService, bigQueryService, initDataProvider(eodhd), initCorporateActionProvider(eodhd), initSymbolDirectory(eodhd), eodhd)
+}
+
+// initEODHDProvider creates the EODHD client, the daily API call limit of the subscription can be set with EODHD_DAILY_LIMIT
+func initEODHDProvider() *marketdata.EODHDProvider {
+	config := marketdata.DefaultEODHDConfig()
+	if value := os.Getenv("EODHD_DAILY_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("Invalid EODHD_DAILY_LIMIT: %v", err)
+		}
+		config.DailyLimit = limit
+	}
+	return marketdata.NewEODHDProviderWithConfig(os.Getenv("EODHD_API_TOKEN"), "", config)
+}
+
+// initCorporateActionProvider chooses where splits and dividends come from, JSON fixtures for local
+// development and EODHD otherwise, cached per symbol. Without fixtures locally, prices are not adjusted.
+func initCorporateActionProvider(eodhd *marketdata.EODHDProvider) marketdata.CorporateActionProvider {
+	// Synthetic series have no splits or dividends
+	if _, enabled := syntheticConfig(); enabled {
+		return nil
+	}
+	if os.Getenv("ENVIRONMENT") == "local" || os.Getenv("FILE_DATA_DIR") != "" {
+		directory := os.Getenv("CORPORATE_ACTIONS_DIR")
+		if directory == "" {
+			log.Println("Warning: CORPORATE_ACTIONS_DIR not set, prices are not adjusted for splits and dividends")
+			return nil
+		}
+		return marketdata.NewLocalCorporateActionProvider(directory)
+	}
+
+	return marketdata.NewCachedCorporateActionProvider(eodhd, marketdata.DefaultCorporateActionTTL)
+}
+
+// initSymbolDirectory chooses where the symbol lists used to validate and search symbols come from,
+// the symbols with data for local development and the EODHD exchange lists otherwise
+func initSymbolDirectory(eodhd *marketdata.EODHDProvider) *marketdata.SymbolDirectory {
+	if os.Getenv("SYMBOL_VALIDATION") == "off" {
+		log.Println("Warning: symbol validation is off")
+		return nil
+	}
+	// Any symbol has synthetic data
+	if _, enabled := syntheticConfig(); enabled {
+		return nil
+	}
+
+	if directory := os.Getenv("FILE_DATA_DIR"); directory != "" {
+		return marketdata.NewSymbolDirectory(marketdata.NewDirectorySymbolList(directory), marketdata.DefaultSymbolListTTL)
+	}
+	if os.Getenv("ENVIRONMENT") == "local" {
+		return marketdata.NewSymbolDirectory(marketdata.NewDirectorySymbolList(localDataDirectory()), marketdata.DefaultSymbolListTTL)
+	}
+
+	return marketdata.NewSymbolDirectory(eodhd, marketdata.DefaultSymbolListTTL)
+}
+
+// localDataDirectory is the directory of the local JSON candles, testdata unless LOCAL_DATA_DIR is set
+func localDataDirectory() string {
+	if directory := os.Getenv("LOCAL_DATA_DIR"); directory != "" {
+		return directory
+	}
+	return "testdata"
+}
+
+// syntheticConfig returns the config of generated market data, from the JSON file in SYNTHETIC_CONFIG or
+// the defaults of the model in SYNTHETIC_MODEL. Synthetic data is off when neither is set.
+func syntheticConfig() (marketdata.SyntheticConfig, bool) {
+	config := marketdata.DefaultSyntheticConfig()
+	if path := os.Getenv("SYNTHETIC_CONFIG"); path != "" {
+		loaded, err := marketdata.LoadSyntheticConfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load synthetic config: %v", err)
+		}
+		return loaded, true
+	}
+
+	model := os.Getenv("SYNTHETIC_MODEL")
+	if model == "" {
+		return config, false
+	}
+	config.Model = model
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid SYNTHETIC_MODEL: %v", err)
+	}
+	return config, true
+}
+
+// initDataProvider chooses where market data comes from, generated data for testing and research,
+// local files for local development and EODHD otherwise
+func initDataProvider(eodhd *marketdata.EODHDProvider) marketdata.MarketDataProvider {
+	if config, enabled := syntheticConfig(); enabled {
+		log.Printf("Using synthetic %s market data with seed %d", config.Model, config.Seed)
+		return marketdata.NewSyntheticProvider(config)
+	}
+
+	if directory := os.Getenv("FILE_DATA_DIR"); directory != "" {
+		format := marketdata.DefaultFileFormat()
+		if path := os.Getenv("FILE_FORMAT_CONFIG"); path != "" {
+			var err error
+			format, err = marketdata.LoadFileFormat(path)
+			if err != nil {
+				log.Fatalf("Failed to load file format: %v", err)
+			}
+		}
+		log.Printf("Using CSV and Parquet market data from %s", directory)
+		return marketdata.NewFileProvider(directory, format)
+	}
+
+	if os.Getenv("ENVIRONMENT") == "local" {
+		directory := localDataDirectory()
+		log.Printf("Using local market data from %s", directory)
+		return marketdata.NewLocalProvider(directory)
+	}
+
+	cacheDirectory := os.Getenv("CANDLE_CACHE_DIR")
+	if cacheDirectory == "off" {
+		return eodhd
+	}
+	if cacheDirectory == "" {
+		cacheDirectory = "candle_cache"
+	}
+	log.Printf("Caching EODHD candles in %s", cacheDirectory)
+	return marketdata.NewCachedProvider(eodhd, "eodhd", cacheDirectory)
 }
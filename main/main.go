@@ -7,6 +7,7 @@ import (
 
 func main() {
 	initEnv()
+	initScoringProfiles()
 	trendHandler := initTrendHandler()
 
 	log.Println("Starting server on :8080..")
@@ -15,6 +16,10 @@ func main() {
 	http.HandleFunc("/trends", trendHandler.GetAllTrends)
 	http.HandleFunc("/saveTrend", trendHandler.SaveTrend)
 	http.HandleFunc("/transactions", trendHandler.GetTransactions)
+	http.HandleFunc("/indicators", trendHandler.GetIndicatorSeries)
+	http.HandleFunc("/report", trendHandler.GetReport)
+	http.HandleFunc("/symbols", trendHandler.SearchSymbols)
+	http.HandleFunc("/quota", trendHandler.GetQuota)
 
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
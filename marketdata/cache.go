@@ -0,0 +1,321 @@
+package marketdata
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+const (
+	cacheDayLayout    = "2006-01-02"
+	cacheManifestName = "manifest.json"
+)
+
+// EODHD publishes intraday candles with a delay, so recent candles are only recorded as cached
+// once they have had this long to arrive
+const cacheSettleDelay = 6 * time.Hour
+
+// CachedSpan records a range fetched from the upstream provider, where it came from and when
+type CachedSpan struct {
+	From      int64     `json:"from"`
+	To        int64     `json:"to"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Candles   int       `json:"candles"`
+}
+
+// CachedProvider keeps the candles of an upstream provider on disk, so repeated requests only
+// fetch the spans that haven't been downloaded before. Candles are stored per symbol, interval
+// and UTC day as gzipped JSON, next to a manifest of the fetched spans:
+//
+//	<directory>/<SYMBOL>/<interval>/2025-06-18.json.gz
+//	<directory>/<SYMBOL>/<interval>/manifest.json
+//
+// A span is only recorded up to the end of the previous UTC day and at most until cacheSettleDelay
+// ago, so candles of an open session or that arrive late are fetched again by later requests.
+type CachedProvider struct {
+	upstream  MarketDataProvider
+	source    string
+	directory string
+	now       func() time.Time
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // By symbol directory, so only requests of the same symbol and interval wait on each other
+}
+
+// NewCachedProvider puts a disk cache in front of upstream, source names the upstream in the manifest
+func NewCachedProvider(upstream MarketDataProvider, source string, directory string) *CachedProvider {
+	return &CachedProvider{
+		upstream:  upstream,
+		source:    source,
+		directory: directory,
+		now:       time.Now,
+		locks:     map[string]*sync.Mutex{},
+	}
+}
+
+// Returns the lock of the symbol directory, held while its candles and manifest are read and written
+func (p *CachedProvider) lock(directory string) *sync.Mutex {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lock, exists := p.locks[directory]
+	if !exists {
+		lock = &sync.Mutex{}
+		p.locks[directory] = lock
+	}
+	return lock
+}
+
+func (p *CachedProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	defer utils.MeasureTime(time.Now(), "CachedProvider.GetIntradayData")
+
+	// Nothing after the current time can be cached, later requests fetch the rest
+	now := p.now()
+	if request.To.IsZero() || request.To.After(now) {
+		request.To = now
+	}
+	if request.From.IsZero() {
+		request.From = request.To.Add(-intervalLookback[request.interval()])
+	}
+
+	directory := p.symbolDirectory(request)
+	lock := p.lock(directory)
+	lock.Lock()
+	defer lock.Unlock()
+
+	spans, err := readManifest(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// The current day and recent candles are still fetched on every request until they are complete
+	settled := min(now.Add(-cacheSettleDelay).Unix(), now.UTC().Truncate(24*time.Hour).Unix()-1)
+
+	for _, missing := range missingSpans(spans, request.From.Unix(), request.To.Unix()) {
+		upstreamRequest := request
+		upstreamRequest.From = time.Unix(missing.From, 0)
+		upstreamRequest.To = time.Unix(missing.To, 0)
+
+		log.Printf("Fetching %s %s from %s between %s and %s", request.Symbol, request.interval(), p.source,
+			upstreamRequest.From.UTC().Format(time.RFC3339), upstreamRequest.To.UTC().Format(time.RFC3339))
+
+		data, err := p.upstream.GetIntradayData(upstreamRequest)
+		if err != nil {
+			return nil, err
+		}
+		data = filterRange(data, upstreamRequest)
+
+		if err := writeCandleDays(directory, data); err != nil {
+			return nil, err
+		}
+
+		missing.To = min(missing.To, settled)
+		if missing.To < missing.From {
+			continue
+		}
+		missing.Source = p.source
+		missing.FetchedAt = now.UTC()
+		missing.Candles = countCandles(data, missing.To)
+		spans = mergeSpans(append(spans, missing))
+		if err := writeManifest(directory, spans); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := readCandleDays(directory, request.From, request.To)
+	if err != nil {
+		return nil, err
+	}
+	return filterRange(data, request), nil
+}
+
+// Spans returns the fetched spans of a symbol and interval, with their provenance
+func (p *CachedProvider) Spans(symbol string, interval string) ([]CachedSpan, error) {
+	directory := p.symbolDirectory(DataRequest{Symbol: symbol, Interval: interval})
+	lock := p.lock(directory)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return readManifest(directory)
+}
+
+func (p *CachedProvider) symbolDirectory(request DataRequest) string {
+	return filepath.Join(p.directory, strings.ToUpper(request.Symbol), request.interval())
+}
+
+// Returns the parts of [from, to] not covered by the spans
+func missingSpans(spans []CachedSpan, from, to int64) []CachedSpan {
+	sorted := append([]CachedSpan{}, spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+
+	missing := []CachedSpan{}
+	cursor := from
+	for _, span := range sorted {
+		if span.To < cursor {
+			continue
+		}
+		if span.From > to {
+			break
+		}
+		if span.From > cursor {
+			missing = append(missing, CachedSpan{From: cursor, To: span.From - 1})
+		}
+		cursor = span.To + 1
+	}
+	if cursor <= to {
+		missing = append(missing, CachedSpan{From: cursor, To: to})
+	}
+	return missing
+}
+
+// Merges overlapping and adjacent spans of the same source, so the manifest doesn't grow with every request
+func mergeSpans(spans []CachedSpan) []CachedSpan {
+	sorted := append([]CachedSpan{}, spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+
+	merged := []CachedSpan{}
+	for _, span := range sorted {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if last.Source == span.Source && span.From <= last.To+1 {
+				last.To = max(last.To, span.To)
+				last.Candles += span.Candles
+				if span.FetchedAt.After(last.FetchedAt) {
+					last.FetchedAt = span.FetchedAt
+				}
+				continue
+			}
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// Number of candles up to and including to
+func countCandles(data []models.IntradayData, to int64) int {
+	count := 0
+	for _, entry := range data {
+		if entry.Timestamp <= to {
+			count++
+		}
+	}
+	return count
+}
+
+func readManifest(directory string) ([]CachedSpan, error) {
+	data, err := os.ReadFile(filepath.Join(directory, cacheManifestName))
+	if os.IsNotExist(err) {
+		return []CachedSpan{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache manifest: %v", err)
+	}
+
+	var spans []CachedSpan
+	if err := json.Unmarshal(data, &spans); err != nil {
+		return nil, fmt.Errorf("failed to parse cache manifest: %v", err)
+	}
+	return spans, nil
+}
+
+func writeManifest(directory string, spans []CachedSpan) error {
+	data, err := json.MarshalIndent(spans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(directory, cacheManifestName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache manifest: %v", err)
+	}
+	return nil
+}
+
+func cacheDayPath(directory string, day string) string {
+	return filepath.Join(directory, day+".json.gz")
+}
+
+// Merges the candles into the day files they belong to
+func writeCandleDays(directory string, data []models.IntradayData) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+
+	days := make(map[string][]models.IntradayData)
+	for _, entry := range data {
+		day := time.Unix(entry.Timestamp, 0).UTC().Format(cacheDayLayout)
+		days[day] = append(days[day], entry)
+	}
+
+	for day, candles := range days {
+		existing, err := readCandleDay(cacheDayPath(directory, day))
+		if err != nil {
+			return err
+		}
+		if err := writeCandleDay(cacheDayPath(directory, day), sortAndDeduplicate(append(existing, candles...))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCandleDay(path string, data []models.IntradayData) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %v", err)
+	}
+	defer f.Close()
+
+	writer := gzip.NewWriter(f)
+	if err := json.NewEncoder(writer).Encode(data); err != nil {
+		return fmt.Errorf("failed to write cache file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %v", err)
+	}
+	return nil
+}
+
+func readCandleDay(path string) ([]models.IntradayData, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []models.IntradayData{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file: %v", err)
+	}
+	defer f.Close()
+
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache file %s: %v", path, err)
+	}
+	defer reader.Close()
+
+	var data []models.IntradayData
+	if err := json.NewDecoder(reader).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse cache file %s: %v", path, err)
+	}
+	return data, nil
+}
+
+// Reads the day files between from and to
+func readCandleDays(directory string, from, to time.Time) ([]models.IntradayData, error) {
+	data := []models.IntradayData{}
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(to); day = day.Add(24 * time.Hour) {
+		candles, err := readCandleDay(cacheDayPath(directory, day.Format(cacheDayLayout)))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, candles...)
+	}
+	return data, nil
+}
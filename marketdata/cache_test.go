@@ -0,0 +1,188 @@
+package marketdata
+
+import (
+	"testing"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Counts the requests passed on to the memory provider
+type countingProvider struct {
+	*MemoryProvider
+	requests []DataRequest
+}
+
+func (p *countingProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	p.requests = append(p.requests, request)
+	return p.MemoryProvider.GetIntradayData(request)
+}
+
+func TestCachedProviderFetchesMissingSpans(t *testing.T) {
+	upstream := &countingProvider{MemoryProvider: NewMemoryProvider()}
+	upstream.Set("AAPL", testCandles(marketOpen, 120))
+
+	provider := NewCachedProvider(upstream, "memory", t.TempDir())
+	provider.now = func() time.Time { return time.Unix(marketOpen, 0).Add(24 * time.Hour) }
+
+	first := DataRequest{Symbol: "AAPL", From: time.Unix(marketOpen, 0), To: time.Unix(marketOpen+59*60, 0)}
+	data, err := provider.GetIntradayData(first)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 60 {
+		t.Errorf("Expected 60 candles; got: %d", len(data))
+	}
+
+	// Served from the cache
+	data, err = provider.GetIntradayData(first)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 60 || len(upstream.requests) != 1 {
+		t.Errorf("Expected 60 cached candles and 1 upstream request; got: %d candles, %d requests", len(data), len(upstream.requests))
+	}
+
+	// Only the second hour is fetched
+	data, err = provider.GetIntradayData(DataRequest{Symbol: "AAPL", From: time.Unix(marketOpen, 0), To: time.Unix(marketOpen+119*60, 0)})
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 120 {
+		t.Errorf("Expected 120 candles; got: %d", len(data))
+	}
+	if len(upstream.requests) != 2 || upstream.requests[1].From.Unix() != marketOpen+59*60+1 {
+		t.Errorf("Expected the second request to start after the cached span; got: %+v", upstream.requests)
+	}
+
+	spans, err := provider.Spans("aapl", "")
+	if err != nil {
+		t.Fatalf("Spans should not give error; got: %s", err.Error())
+	}
+	// The adjacent spans are merged
+	if len(spans) != 1 || spans[0].Source != "memory" || spans[0].FetchedAt.IsZero() || spans[0].Candles != 120 {
+		t.Errorf("Unexpected cached spans: %+v", spans)
+	}
+}
+
+func TestCachedProviderRefetchesCurrentDay(t *testing.T) {
+	upstream := &countingProvider{MemoryProvider: NewMemoryProvider()}
+	upstream.Set("AAPL", testCandles(marketOpen, 30))
+
+	// Half an hour into the session only 30 candles exist upstream
+	provider := NewCachedProvider(upstream, "memory", t.TempDir())
+	provider.now = func() time.Time { return time.Unix(marketOpen+30*60, 0) }
+
+	request := DataRequest{Symbol: "AAPL", From: time.Unix(marketOpen, 0)}
+	if _, err := provider.GetIntradayData(request); err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	spans, err := provider.Spans("AAPL", "")
+	if err != nil {
+		t.Fatalf("Spans should not give error; got: %s", err.Error())
+	}
+	if len(spans) != 0 {
+		t.Errorf("Expected no cached span for the current day; got: %+v", spans)
+	}
+
+	// The rest of the session arrives and is fetched by the next request
+	upstream.Set("AAPL", testCandles(marketOpen, 60))
+	provider.now = func() time.Time { return time.Unix(marketOpen+60*60, 0) }
+
+	data, err := provider.GetIntradayData(request)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 60 || len(upstream.requests) != 2 {
+		t.Errorf("Expected 60 candles after fetching the current day again; got: %d candles, %d requests", len(data), len(upstream.requests))
+	}
+}
+
+// Upstream that blocks fetching AAPL until released
+type blockingProvider struct {
+	*MemoryProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	if request.Symbol == "AAPL" {
+		close(p.started)
+		<-p.release
+	}
+	return p.MemoryProvider.GetIntradayData(request)
+}
+
+func TestCachedProviderLocksPerSymbol(t *testing.T) {
+	upstream := &blockingProvider{MemoryProvider: NewMemoryProvider(), started: make(chan struct{}), release: make(chan struct{})}
+	upstream.Set("AAPL", testCandles(marketOpen, 30))
+	upstream.Set("MSFT", testCandles(marketOpen, 30))
+
+	provider := NewCachedProvider(upstream, "memory", t.TempDir())
+	provider.now = func() time.Time { return time.Unix(marketOpen, 0).Add(24 * time.Hour) }
+	request := DataRequest{Symbol: "AAPL", From: time.Unix(marketOpen, 0), To: time.Unix(marketOpen+29*60, 0)}
+
+	done := make(chan error)
+	go func() {
+		_, err := provider.GetIntradayData(request)
+		done <- err
+	}()
+	<-upstream.started
+
+	// Another symbol doesn't wait for the slow fetch of AAPL
+	request.Symbol = "MSFT"
+	data, err := provider.GetIntradayData(request)
+	if err != nil || len(data) != 30 {
+		t.Errorf("Expected 30 MSFT candles while AAPL is fetched; got: %d, %v", len(data), err)
+	}
+
+	close(upstream.release)
+	if err := <-done; err != nil {
+		t.Errorf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+}
+
+func TestMergeSpans(t *testing.T) {
+	first := time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	spans := []CachedSpan{
+		{From: 20, To: 29, Source: "eodhd", FetchedAt: second, Candles: 10},
+		{From: 0, To: 9, Source: "eodhd", FetchedAt: first, Candles: 10},
+		{From: 10, To: 19, Source: "eodhd", FetchedAt: first, Candles: 10},
+		{From: 25, To: 40, Source: "eodhd", FetchedAt: first, Candles: 5},
+		{From: 50, To: 59, Source: "eodhd", FetchedAt: first, Candles: 10},
+	}
+
+	merged := mergeSpans(spans)
+	expected := []CachedSpan{
+		{From: 0, To: 40, Source: "eodhd", FetchedAt: second, Candles: 35},
+		{From: 50, To: 59, Source: "eodhd", FetchedAt: first, Candles: 10},
+	}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %d merged spans; got: %+v", len(expected), merged)
+	}
+	for i := range expected {
+		if merged[i] != expected[i] {
+			t.Errorf("Expected merged span %+v; got: %+v", expected[i], merged[i])
+		}
+	}
+}
+
+func TestMissingSpans(t *testing.T) {
+	spans := []CachedSpan{{From: 10, To: 19}, {From: 30, To: 39}}
+
+	missing := missingSpans(spans, 0, 50)
+	expected := []CachedSpan{{From: 0, To: 9}, {From: 20, To: 29}, {From: 40, To: 50}}
+	if len(missing) != len(expected) {
+		t.Fatalf("Expected %d missing spans; got: %+v", len(expected), missing)
+	}
+	for i := range expected {
+		if missing[i] != expected[i] {
+			t.Errorf("Expected missing span %+v; got: %+v", expected[i], missing[i])
+		}
+	}
+
+	if len(missingSpans(spans, 12, 18)) != 0 {
+		t.Errorf("Expected no missing spans within a cached span")
+	}
+}
@@ -0,0 +1,233 @@
+package marketdata
+
+import (
+	"sync"
+	"time"
+)
+
+// Trading days in a year, used to annualize over trading time
+const TradingDaysPerYear = 252
+
+const calendarDayLayout = "2006-01-02"
+
+// MarketCalendar knows the days an exchange is closed or closes early. Weekends are closed on
+// every exchange and don't need to be part of a calendar.
+type MarketCalendar interface {
+	// Holiday reports whether the exchange is closed on the date of day
+	Holiday(day time.Time) bool
+	// EarlyClose returns the close of a half day on the date of day, as an offset from midnight
+	EarlyClose(day time.Time) (time.Duration, bool)
+}
+
+// Holidays and half days of one year
+type calendarYear struct {
+	holidays    map[string]bool
+	earlyCloses map[string]time.Duration
+}
+
+// ruleCalendar builds the holidays and half days of each year from rules, and keeps them once built
+type ruleCalendar struct {
+	rules func(year int) calendarYear
+	mu    sync.Mutex
+	years map[int]calendarYear
+}
+
+func newRuleCalendar(rules func(year int) calendarYear) *ruleCalendar {
+	return &ruleCalendar{rules: rules, years: make(map[int]calendarYear)}
+}
+
+func (c *ruleCalendar) year(year int) calendarYear {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	days, exists := c.years[year]
+	if !exists {
+		days = c.rules(year)
+		c.years[year] = days
+	}
+	return days
+}
+
+func (c *ruleCalendar) Holiday(day time.Time) bool {
+	return c.year(day.Year()).holidays[day.Format(calendarDayLayout)]
+}
+
+func (c *ruleCalendar) EarlyClose(day time.Time) (time.Duration, bool) {
+	close, exists := c.year(day.Year()).earlyCloses[day.Format(calendarDayLayout)]
+	return close, exists
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// The n-th weekday of a month, a negative n counts from the end of the month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := date(year, month+1, 0)
+		return last.AddDate(0, 0, -((int(last.Weekday())-int(weekday)+7)%7 + (-n-1)*7))
+	}
+	first := date(year, month, 1)
+	return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+(n-1)*7)
+}
+
+// Easter Sunday in the Gregorian calendar
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
+
+// Holidays on a Saturday are observed the Friday before, on a Sunday the Monday after
+func observed(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+// NYSE holidays and the 13:00 closes around Independence Day, Thanksgiving and Christmas
+func nyseYear(year int) calendarYear {
+	days := calendarYear{holidays: make(map[string]bool), earlyCloses: make(map[string]time.Duration)}
+	holiday := func(day time.Time) { days.holidays[day.Format(calendarDayLayout)] = true }
+	earlyClose := func(day time.Time) {
+		if day.Weekday() >= time.Monday && day.Weekday() <= time.Thursday {
+			days.earlyCloses[day.Format(calendarDayLayout)] = clock(13, 0)
+		}
+	}
+
+	// New Year's Day on a Saturday isn't observed on the Friday before, that would be in the old year
+	if newYear := date(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		holiday(observed(newYear))
+	}
+	holiday(nthWeekday(year, time.January, time.Monday, 3))  // Martin Luther King Jr. Day
+	holiday(nthWeekday(year, time.February, time.Monday, 3)) // Washington's Birthday
+	holiday(easter(year).AddDate(0, 0, -2))                  // Good Friday
+	holiday(nthWeekday(year, time.May, time.Monday, -1))     // Memorial Day
+	if year >= 2022 {
+		holiday(observed(date(year, time.June, 19))) // Juneteenth
+	}
+	holiday(observed(date(year, time.July, 4)))
+	holiday(nthWeekday(year, time.September, time.Monday, 1))
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	holiday(thanksgiving)
+	holiday(observed(date(year, time.December, 25)))
+
+	earlyClose(date(year, time.July, 3))
+	days.earlyCloses[thanksgiving.AddDate(0, 0, 1).Format(calendarDayLayout)] = clock(13, 0)
+	earlyClose(date(year, time.December, 24))
+
+	return days
+}
+
+// Oslo Børs is closed on the Norwegian public holidays and on Christmas and New Year's Eve
+func osloYear(year int) calendarYear {
+	days := calendarYear{holidays: make(map[string]bool), earlyCloses: make(map[string]time.Duration)}
+	holiday := func(day time.Time) { days.holidays[day.Format(calendarDayLayout)] = true }
+
+	easterSunday := easter(year)
+	holiday(date(year, time.January, 1))
+	holiday(easterSunday.AddDate(0, 0, -3)) // Maundy Thursday
+	holiday(easterSunday.AddDate(0, 0, -2)) // Good Friday
+	holiday(easterSunday.AddDate(0, 0, 1))  // Easter Monday
+	holiday(date(year, time.May, 1))
+	holiday(date(year, time.May, 17))
+	holiday(easterSunday.AddDate(0, 0, 39)) // Ascension Day
+	holiday(easterSunday.AddDate(0, 0, 50)) // Whit Monday
+	holiday(date(year, time.December, 24))
+	holiday(date(year, time.December, 25))
+	holiday(date(year, time.December, 26))
+	holiday(date(year, time.December, 31))
+
+	return days
+}
+
+var (
+	nyseCalendar = newRuleCalendar(nyseYear)
+	osloCalendar = newRuleCalendar(osloYear)
+)
+
+// IsTradingDay reports whether the exchange is open on the date of a time in its time zone
+func (e Exchange) IsTradingDay(local time.Time) bool {
+	if isWeekend(local) {
+		return false
+	}
+	return e.Calendar == nil || !e.Calendar.Holiday(local)
+}
+
+// SessionsOn returns the sessions on the date of a time in the exchange time zone. There are none
+// on weekends and holidays, and on half days the sessions end at the early close.
+func (e Exchange) SessionsOn(local time.Time) []Session {
+	if !e.IsTradingDay(local) {
+		return nil
+	}
+	if e.Calendar == nil {
+		return e.Sessions
+	}
+
+	earlyClose, isHalfDay := e.Calendar.EarlyClose(local)
+	if !isHalfDay {
+		return e.Sessions
+	}
+
+	sessions := []Session{}
+	for _, session := range e.Sessions {
+		if session.Open >= earlyClose {
+			break
+		}
+		if session.Close > earlyClose {
+			session.Close = earlyClose
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// SessionClose returns the end of the last session on the date of a time in the exchange time zone
+func (e Exchange) SessionClose(local time.Time) (time.Time, bool) {
+	sessions := e.SessionsOn(local)
+	if len(sessions) == 0 {
+		return time.Time{}, false
+	}
+	return at(local, sessions[len(sessions)-1].Close), true
+}
+
+// TradingDays counts the trading days from the date of start to the date of end
+func (e Exchange) TradingDays(start, end time.Time) int {
+	location, err := e.Location()
+	if err != nil {
+		location = time.UTC
+	}
+
+	days := 0
+	for day := at(start.In(location), 0); !day.After(end.In(location)); day = day.AddDate(0, 0, 1) {
+		if e.IsTradingDay(day) {
+			days++
+		}
+	}
+	return days
+}
+
+// TradingYears is the length of the period from start to end in trading years
+func (e Exchange) TradingYears(start, end time.Time) float64 {
+	return float64(e.TradingDays(start, end)) / TradingDaysPerYear
+}
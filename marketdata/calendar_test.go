@@ -0,0 +1,123 @@
+package marketdata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNYSECalendar(t *testing.T) {
+	us, _ := LookupExchange("US")
+	location, _ := us.Location()
+
+	holidays := []string{
+		"2025-01-01", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26", "2025-06-19",
+		"2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25",
+		"2022-12-26", // Christmas on a Sunday is observed on Monday
+		"2026-07-03", // Independence Day on a Saturday is observed on Friday
+	}
+	for _, holiday := range holidays {
+		day, _ := time.ParseInLocation("2006-01-02", holiday, location)
+		if us.IsTradingDay(day) {
+			t.Errorf("Expected %s to be a NYSE holiday", holiday)
+		}
+	}
+
+	// New Year's Day 2022 was a Saturday and wasn't observed on Friday 2021-12-31
+	day, _ := time.ParseInLocation("2006-01-02", "2021-12-31", location)
+	if !us.IsTradingDay(day) {
+		t.Errorf("Expected 2021-12-31 to be a trading day")
+	}
+
+	halfDays := []string{"2025-07-03", "2025-11-28", "2025-12-24"}
+	for _, halfDay := range halfDays {
+		day, _ := time.ParseInLocation("2006-01-02", halfDay, location)
+		close, isTradingDay := us.SessionClose(day)
+		if !isTradingDay || close.Hour() != 13 || close.Minute() != 0 {
+			t.Errorf("Expected the session to close at 13:00 on %s; got: %s", halfDay, close)
+		}
+	}
+}
+
+func TestSessionCloseAcrossDaylightSavingTime(t *testing.T) {
+	us, _ := LookupExchange("US")
+	location, _ := us.Location()
+
+	// The close is 16:00 New York time in summer (20:00 UTC) and winter (21:00 UTC)
+	summer, _ := us.SessionClose(time.Date(2025, 6, 18, 10, 0, 0, 0, location))
+	winter, _ := us.SessionClose(time.Date(2025, 12, 18, 10, 0, 0, 0, location))
+	if summer.UTC().Hour() != 20 || winter.UTC().Hour() != 21 {
+		t.Errorf("Expected the close at 20:00 UTC in summer and 21:00 UTC in winter; got: %s and %s", summer.UTC(), winter.UTC())
+	}
+
+	// The first Monday after clocks went forward still opens at 9:30
+	if !us.InSession(time.Date(2025, 3, 10, 9, 30, 0, 0, location)) || us.InSession(time.Date(2025, 3, 10, 9, 29, 0, 0, location)) {
+		t.Errorf("Expected the session to open at 9:30 after the change to daylight saving time")
+	}
+}
+
+func TestSessionEnds(t *testing.T) {
+	us, _ := LookupExchange("US")
+
+	// 2025-11-28 is a half day closing at 13:00 New York time (18:00 UTC)
+	halfDayClose := time.Date(2025, 11, 28, 18, 0, 0, 0, time.UTC).Unix()
+	data := testCandles(halfDayClose-3*60, 3)
+	data = append(data, testCandles(marketOpen, 2)...)
+	data[3].Timestamp = time.Date(2025, 12, 1, 14, 30, 0, 0, time.UTC).Unix()
+	data[4].Timestamp = data[3].Timestamp + 60
+
+	ends, err := SessionEnds(data, us, Interval1Minute)
+	if err != nil {
+		t.Fatalf("SessionEnds should not give error; got: %s", err.Error())
+	}
+
+	expected := []bool{false, false, true, false, false}
+	for i := range expected {
+		if ends[i] != expected[i] {
+			t.Errorf("Expected session end %t at %d; got: %t", expected[i], i, ends[i])
+		}
+	}
+}
+
+func TestSessionStarts(t *testing.T) {
+	us, _ := LookupExchange("US")
+
+	// Pre-market at 9:28 ET, the open, a pause of 4 hours in an illiquid symbol and the next day
+	data := testCandles(marketOpen-2*60, 4)
+	data[3].Timestamp = marketOpen + 4*60*60
+	data = append(data, testCandles(marketOpen+24*60*60, 1)...)
+
+	starts, err := SessionStarts(data, us)
+	if err != nil {
+		t.Fatalf("SessionStarts should not give error; got: %s", err.Error())
+	}
+
+	expected := []bool{true, false, true, false, true}
+	for i := range expected {
+		if starts[i] != expected[i] {
+			t.Errorf("Expected session start %t at %d; got: %t", expected[i], i, starts[i])
+		}
+	}
+
+	// Hong Kong continues the session after lunch, 2025-06-18 11:59 and 13:00 local time
+	hongKong, _ := LookupExchange("HK")
+	data = testCandles(time.Date(2025, 6, 18, 3, 59, 0, 0, time.UTC).Unix(), 2)
+	data[1].Timestamp = time.Date(2025, 6, 18, 5, 0, 0, 0, time.UTC).Unix()
+	starts, _ = SessionStarts(data, hongKong)
+	if starts[1] {
+		t.Errorf("Expected no new session after the lunch break")
+	}
+}
+
+func TestTradingYears(t *testing.T) {
+	us, _ := LookupExchange("US")
+
+	// 2024 had 252 NYSE trading days
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 12, 31, 20, 0, 0, 0, time.UTC)
+	if days := us.TradingDays(start, end); days != 252 {
+		t.Errorf("Expected 252 trading days in 2024; got: %d", days)
+	}
+	if years := us.TradingYears(start, end); years != 1 {
+		t.Errorf("Expected 1 trading year in 2024; got: %.3f", years)
+	}
+}
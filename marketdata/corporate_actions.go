@@ -0,0 +1,295 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Split of the shares, Ratio is the number of new shares per old share, 4 for a 4:1 split
+type Split struct {
+	Date  string  `json:"date"` // First trading day at the new basis, YYYY-MM-DD
+	Ratio float64 `json:"ratio"`
+}
+
+// Dividend paid per share, Date is the ex-dividend date. Amount is as paid, not adjusted for later splits.
+type Dividend struct {
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+}
+
+// CorporateActions are the events changing the price basis of a symbol
+type CorporateActions struct {
+	Splits    []Split    `json:"splits"`
+	Dividends []Dividend `json:"dividends"`
+}
+
+// CorporateActionProvider fetches the splits and dividends of a symbol between from and to
+type CorporateActionProvider interface {
+	GetCorporateActions(symbol string, from, to time.Time) (CorporateActions, error)
+}
+
+// Price series choices of /checkMarket
+const (
+	PricesRaw      = "raw"
+	PricesAdjusted = "adjusted"
+)
+
+// AdjustPrices back-adjusts the candles for splits and dividends, so they are on the price basis of
+// the last candle. Candles before a split are divided by its ratio and their volume multiplied by it.
+// Candles before an ex-dividend date are multiplied by 1 - dividend / the last close before that date,
+// taking the dividends in date order so that close isn't scaled by a later dividend yet. Dates are
+// compared in the time zone of the exchange. Only the given actions are applied, so the prices are on
+// the basis of the last candle even when the symbol split after it.
+func AdjustPrices(data []models.IntradayData, actions CorporateActions, location *time.Location) []models.IntradayData {
+	adjusted := append([]models.IntradayData{}, data...)
+	if len(adjusted) == 0 {
+		return adjusted
+	}
+
+	dates := make([]string, len(adjusted))
+	for i, candle := range adjusted {
+		dates[i] = time.Unix(candle.Timestamp, 0).In(location).Format(calendarDayLayout)
+	}
+
+	// Index of the first candle on or after a date
+	firstOn := func(date string) int {
+		return sort.SearchStrings(dates, date)
+	}
+
+	for _, split := range actions.Splits {
+		if split.Ratio <= 0 || split.Ratio == 1 {
+			continue
+		}
+		for i := 0; i < firstOn(split.Date); i++ {
+			adjusted[i].Open /= split.Ratio
+			adjusted[i].High /= split.Ratio
+			adjusted[i].Low /= split.Ratio
+			adjusted[i].Close /= split.Ratio
+			adjusted[i].Volume = int(float64(adjusted[i].Volume)*split.Ratio + 0.5)
+		}
+	}
+
+	// Dividends are applied on the split adjusted prices, so the paid amount is divided by the same
+	// splits as the close before the ex-date: the ones on or after that date
+	dividends := append([]Dividend{}, actions.Dividends...)
+	sort.SliceStable(dividends, func(i, j int) bool { return dividends[i].Date < dividends[j].Date })
+	for _, dividend := range dividends {
+		exDate := firstOn(dividend.Date)
+		if exDate == 0 || exDate == len(adjusted) || dividend.Amount <= 0 {
+			continue
+		}
+		amount := dividend.Amount
+		for _, split := range actions.Splits {
+			if split.Ratio > 0 && split.Date >= dividend.Date {
+				amount /= split.Ratio
+			}
+		}
+		previousClose := adjusted[exDate-1].Close
+		if previousClose <= amount {
+			continue
+		}
+		factor := 1 - amount/previousClose
+		for i := 0; i < exDate; i++ {
+			adjusted[i].Open *= factor
+			adjusted[i].High *= factor
+			adjusted[i].Low *= factor
+			adjusted[i].Close *= factor
+		}
+	}
+
+	return adjusted
+}
+
+// LocalCorporateActionProvider reads corporate actions from JSON fixtures, one file per symbol:
+// <directory>/<SYMBOL>.json with the splits and dividends of CorporateActions.
+type LocalCorporateActionProvider struct {
+	directory string
+}
+
+func NewLocalCorporateActionProvider(directory string) *LocalCorporateActionProvider {
+	return &LocalCorporateActionProvider{directory: directory}
+}
+
+func (p *LocalCorporateActionProvider) GetCorporateActions(symbol string, from, to time.Time) (CorporateActions, error) {
+	parsed, err := ParseSymbol(symbol)
+	if err != nil {
+		return CorporateActions{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.directory, parsed.String()+".json"))
+	if os.IsNotExist(err) {
+		return CorporateActions{}, nil
+	}
+	if err != nil {
+		return CorporateActions{}, fmt.Errorf("failed to read corporate actions: %v", err)
+	}
+
+	var actions CorporateActions
+	if err := json.Unmarshal(data, &actions); err != nil {
+		return CorporateActions{}, fmt.Errorf("failed to parse corporate actions of %s: %v", symbol, err)
+	}
+	return actions.between(from, to), nil
+}
+
+// How long the corporate actions of a symbol are used before they're fetched again
+const DefaultCorporateActionTTL = 24 * time.Hour
+
+// Corporate actions of a symbol over its whole history
+type cachedCorporateActions struct {
+	actions   CorporateActions
+	fetchedAt time.Time
+}
+
+// CachedCorporateActionProvider keeps the corporate actions of each symbol in memory for the TTL, so
+// a run only calls the upstream provider when a symbol is first seen. The whole history is fetched
+// and cut to the requested range.
+type CachedCorporateActionProvider struct {
+	upstream CorporateActionProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	symbols map[string]cachedCorporateActions
+}
+
+func NewCachedCorporateActionProvider(upstream CorporateActionProvider, ttl time.Duration) *CachedCorporateActionProvider {
+	return &CachedCorporateActionProvider{
+		upstream: upstream,
+		ttl:      ttl,
+		now:      time.Now,
+		symbols:  map[string]cachedCorporateActions{},
+	}
+}
+
+func (p *CachedCorporateActionProvider) GetCorporateActions(symbol string, from, to time.Time) (CorporateActions, error) {
+	parsed, err := ParseSymbol(symbol)
+	if err != nil {
+		return CorporateActions{}, err
+	}
+	key := parsed.String()
+
+	p.mu.Lock()
+	cached, exists := p.symbols[key]
+	p.mu.Unlock()
+	if exists && p.now().Sub(cached.fetchedAt) < p.ttl {
+		return cached.actions.between(from, to), nil
+	}
+
+	// The lock isn't held while fetching, so other symbols don't wait for it
+	actions, err := p.upstream.GetCorporateActions(symbol, time.Time{}, time.Time{})
+	if err != nil {
+		return CorporateActions{}, err
+	}
+
+	p.mu.Lock()
+	p.symbols[key] = cachedCorporateActions{actions: actions, fetchedAt: p.now()}
+	p.mu.Unlock()
+
+	return actions.between(from, to), nil
+}
+
+// Keeps the actions from the date of from to the date of to, zero times mean no limit
+func (a CorporateActions) between(from, to time.Time) CorporateActions {
+	inRange := func(date string) bool {
+		return (from.IsZero() || date >= from.UTC().Format(calendarDayLayout)) &&
+			(to.IsZero() || date <= to.UTC().Format(calendarDayLayout))
+	}
+
+	filtered := CorporateActions{Splits: []Split{}, Dividends: []Dividend{}}
+	for _, split := range a.Splits {
+		if inRange(split.Date) {
+			filtered.Splits = append(filtered.Splits, split)
+		}
+	}
+	for _, dividend := range a.Dividends {
+		if inRange(dividend.Date) {
+			filtered.Dividends = append(filtered.Dividends, dividend)
+		}
+	}
+	return filtered
+}
+
+// Split of the EODHD splits endpoint, like "4.000000/1.000000"
+type eodhdSplit struct {
+	Date  string `json:"date"`
+	Split string `json:"split"`
+}
+
+// Dividend of the EODHD dividends endpoint, the date is the ex-dividend date. Value is adjusted for all
+// later splits, also the ones after the requested range, so the paid UnadjustedValue is used instead.
+type eodhdDividend struct {
+	Date            string  `json:"date"`
+	Value           float64 `json:"value"`
+	UnadjustedValue float64 `json:"unadjustedValue"`
+}
+
+// GetCorporateActions fetches splits and dividends from the EODHD splits and dividends endpoints
+func (p *EODHDProvider) GetCorporateActions(symbol string, from, to time.Time) (CorporateActions, error) {
+	if p.apiToken == "" {
+		return CorporateActions{}, fmt.Errorf("%w: API token is not set", ErrUnauthorized)
+	}
+
+	parsed, err := ParseSymbol(symbol)
+	if err != nil {
+		return CorporateActions{}, err
+	}
+
+	query := url.Values{}
+	query.Set("api_token", p.apiToken)
+	query.Set("fmt", "json")
+	if !from.IsZero() {
+		query.Set("from", from.UTC().Format(calendarDayLayout))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.UTC().Format(calendarDayLayout))
+	}
+
+	var splits []eodhdSplit
+	if err := p.get(fmt.Sprintf("%s/splits/%s?%s", p.baseURL, parsed.EODHDCode(), query.Encode()), &splits); err != nil {
+		return CorporateActions{}, err
+	}
+
+	var dividends []eodhdDividend
+	if err := p.get(fmt.Sprintf("%s/div/%s?%s", p.baseURL, parsed.EODHDCode(), query.Encode()), &dividends); err != nil {
+		return CorporateActions{}, err
+	}
+
+	actions := CorporateActions{Splits: []Split{}, Dividends: []Dividend{}}
+	for _, split := range splits {
+		ratio, err := parseSplitRatio(split.Split)
+		if err != nil {
+			return CorporateActions{}, fmt.Errorf("invalid split of %s on %s: %v", symbol, split.Date, err)
+		}
+		actions.Splits = append(actions.Splits, Split{Date: split.Date, Ratio: ratio})
+	}
+	for _, dividend := range dividends {
+		actions.Dividends = append(actions.Dividends, Dividend{Date: dividend.Date, Amount: dividend.UnadjustedValue})
+	}
+	return actions, nil
+}
+
+// Parses a split like "4.000000/1.000000" into the number of new shares per old share
+func parseSplitRatio(split string) (float64, error) {
+	newShares, oldShares, found := strings.Cut(split, "/")
+	if !found {
+		return 0, fmt.Errorf("expected new/old shares, got %q", split)
+	}
+	numerator, err := strconv.ParseFloat(strings.TrimSpace(newShares), 64)
+	if err != nil {
+		return 0, err
+	}
+	denominator, err := strconv.ParseFloat(strings.TrimSpace(oldShares), 64)
+	if err != nil || denominator == 0 {
+		return 0, fmt.Errorf("invalid old shares in %q", split)
+	}
+	return numerator / denominator, nil
+}
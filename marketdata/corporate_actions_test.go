@@ -0,0 +1,236 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAdjustPricesForSplitAndDividend(t *testing.T) {
+	location, _ := time.LoadLocation("America/New_York")
+
+	// One candle a day from 2025-06-18 to 2025-06-20, with a 4:1 split on the 19th
+	data := testCandles(marketOpen, 3)
+	for i := range data {
+		data[i].Timestamp = marketOpen + int64(i)*86400
+	}
+	data[0].Open, data[0].High, data[0].Low, data[0].Close = 400, 404, 396, 400
+	data[1].Open, data[1].High, data[1].Low, data[1].Close = 100, 101, 99, 100
+	data[2].Open, data[2].High, data[2].Low, data[2].Close = 99, 100, 98, 99
+
+	actions := CorporateActions{
+		Splits:    []Split{{Date: "2025-06-19", Ratio: 4}},
+		Dividends: []Dividend{{Date: "2025-06-20", Amount: 1}},
+	}
+	adjusted := AdjustPrices(data, actions, location)
+
+	// The split makes the first candle 100, the dividend scales the first two by 1 - 1/100
+	expected := []float64{99, 99, 99}
+	for i := range expected {
+		if math.Abs(adjusted[i].Close-expected[i]) > 1e-9 {
+			t.Errorf("Expected adjusted close %.2f at %d; got: %.4f", expected[i], i, adjusted[i].Close)
+		}
+	}
+	if adjusted[0].Volume != 4000 || adjusted[1].Volume != 1000 {
+		t.Errorf("Expected the volume before the split to be multiplied by 4; got: %d and %d", adjusted[0].Volume, adjusted[1].Volume)
+	}
+	if data[0].Close != 400 {
+		t.Errorf("AdjustPrices should not change the raw candles")
+	}
+}
+
+func TestAdjustPricesForDividendsInAnyOrder(t *testing.T) {
+	location, _ := time.LoadLocation("America/New_York")
+
+	// One candle a day from 2025-06-18 to 2025-06-20 with a dividend on the 19th and the 20th
+	data := testCandles(marketOpen, 3)
+	for i := range data {
+		data[i].Timestamp = marketOpen + int64(i)*86400
+	}
+	data[0].Close, data[1].Close, data[2].Close = 100, 50, 50
+
+	inOrder := CorporateActions{Dividends: []Dividend{{Date: "2025-06-19", Amount: 10}, {Date: "2025-06-20", Amount: 5}}}
+	reversed := CorporateActions{Dividends: []Dividend{inOrder.Dividends[1], inOrder.Dividends[0]}}
+
+	// The first dividend is 10% of the raw close of 100, the second 10% of the close of 50
+	expected := []float64{100 * 0.9 * 0.9, 50 * 0.9, 50}
+	for _, actions := range []CorporateActions{inOrder, reversed} {
+		adjusted := AdjustPrices(data, actions, location)
+		for i := range expected {
+			if math.Abs(adjusted[i].Close-expected[i]) > 1e-9 {
+				t.Errorf("Expected adjusted close %.2f at %d; got: %.4f", expected[i], i, adjusted[i].Close)
+			}
+		}
+	}
+}
+
+func TestAdjustPricesForSplitAfterDividend(t *testing.T) {
+	location, _ := time.LoadLocation("America/New_York")
+
+	// One candle a day from 2025-06-18 to 2025-06-20 with a dividend on the 19th
+	data := testCandles(marketOpen, 3)
+	for i := range data {
+		data[i].Timestamp = marketOpen + int64(i)*86400
+	}
+
+	tests := []struct {
+		name     string
+		closes   []float64
+		actions  CorporateActions
+		expected []float64
+	}{
+		// A 4:1 split on the 20th divides the paid 4 by 4, 1% of the adjusted close of 100
+		{"split in range", []float64{400, 400, 100}, CorporateActions{
+			Splits:    []Split{{Date: "2025-06-20", Ratio: 4}},
+			Dividends: []Dividend{{Date: "2025-06-19", Amount: 4}},
+		}, []float64{99, 100, 100}},
+		// A split after the data range isn't in the actions, and doesn't change the paid 4
+		{"split after range", []float64{400, 400, 400}, CorporateActions{
+			Dividends: []Dividend{{Date: "2025-06-19", Amount: 4}},
+		}, []float64{396, 400, 400}},
+	}
+
+	for _, test := range tests {
+		for i := range data {
+			data[i].Close = test.closes[i]
+		}
+		adjusted := AdjustPrices(data, test.actions, location)
+		for i := range test.expected {
+			if math.Abs(adjusted[i].Close-test.expected[i]) > 1e-9 {
+				t.Errorf("%s: Expected adjusted close %.2f at %d; got: %.4f", test.name, test.expected[i], i, adjusted[i].Close)
+			}
+		}
+	}
+}
+
+// Counts the requests passed on to a corporate action provider
+type countingCorporateActions struct {
+	CorporateActionProvider
+	requests int
+}
+
+func (p *countingCorporateActions) GetCorporateActions(symbol string, from, to time.Time) (CorporateActions, error) {
+	p.requests++
+	return p.CorporateActionProvider.GetCorporateActions(symbol, from, to)
+}
+
+func TestCachedCorporateActionProvider(t *testing.T) {
+	directory := t.TempDir()
+	content, _ := json.Marshal(CorporateActions{
+		Splits: []Split{{Date: "2020-08-31", Ratio: 4}, {Date: "2014-06-09", Ratio: 7}},
+	})
+	if err := os.WriteFile(filepath.Join(directory, "AAPL.json"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := &countingCorporateActions{CorporateActionProvider: NewLocalCorporateActionProvider(directory)}
+	provider := NewCachedCorporateActionProvider(upstream, time.Hour)
+	now := time.Date(2025, 6, 18, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	actions, err := provider.GetCorporateActions("AAPL", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetCorporateActions should not give error; got: %s", err.Error())
+	}
+	if len(actions.Splits) != 1 || actions.Splits[0].Ratio != 4 {
+		t.Errorf("Expected only the 2020 split; got: %+v", actions)
+	}
+
+	// Another range of the same symbol is cut from the cached history
+	actions, _ = provider.GetCorporateActions("aapl.us", time.Time{}, time.Time{})
+	if len(actions.Splits) != 2 || upstream.requests != 1 {
+		t.Errorf("Expected both splits from 1 upstream request; got: %d splits, %d requests", len(actions.Splits), upstream.requests)
+	}
+
+	now = now.Add(2 * time.Hour)
+	provider.GetCorporateActions("AAPL", time.Time{}, time.Time{})
+	if upstream.requests != 2 {
+		t.Errorf("Expected the expired actions to be fetched again; got: %d requests", upstream.requests)
+	}
+}
+
+func TestLocalCorporateActionProvider(t *testing.T) {
+	directory := t.TempDir()
+	content, _ := json.Marshal(CorporateActions{
+		Splits:    []Split{{Date: "2020-08-31", Ratio: 4}, {Date: "2014-06-09", Ratio: 7}},
+		Dividends: []Dividend{{Date: "2020-08-07", Amount: 0.82}},
+	})
+	if err := os.WriteFile(filepath.Join(directory, "AAPL.json"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewLocalCorporateActionProvider(directory)
+	actions, err := provider.GetCorporateActions("aapl", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetCorporateActions should not give error; got: %s", err.Error())
+	}
+	if len(actions.Splits) != 1 || actions.Splits[0].Ratio != 4 || len(actions.Dividends) != 1 {
+		t.Errorf("Expected the 2020 split and dividend; got: %+v", actions)
+	}
+
+	actions, err = provider.GetCorporateActions("MSFT", time.Time{}, time.Time{})
+	if err != nil || len(actions.Splits) != 0 {
+		t.Errorf("Expected no corporate actions without a fixture; got: %+v, %v", actions, err)
+	}
+}
+
+func TestEODHDCorporateActions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/splits/AAPL.US":
+			w.Write([]byte(`[{"date":"2020-08-31","split":"4.000000/1.000000"}]`))
+		case "/div/AAPL.US":
+			w.Write([]byte(`[{"date":"2020-08-07","value":0.205,"unadjustedValue":0.82}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	actions, err := NewEODHDProvider("token", server.URL).GetCorporateActions("AAPL", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetCorporateActions should not give error; got: %s", err.Error())
+	}
+	if len(actions.Splits) != 1 || actions.Splits[0].Ratio != 4 || len(actions.Dividends) != 1 || actions.Dividends[0].Amount != 0.82 {
+		t.Errorf("Unexpected corporate actions: %+v", actions)
+	}
+}
+
+func TestEODHDDividendWithSplitAfterRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/splits/AAPL.US":
+			w.Write([]byte(`[{"date":"2020-08-31","split":"4.000000/1.000000"}]`))
+		case "/div/AAPL.US":
+			w.Write([]byte(`[{"date":"2020-08-07","value":0.205,"unadjustedValue":0.82}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Candles of 2020-08-06 and 2020-08-07 at the open, before the split on 2020-08-31
+	from := time.Date(2020, 8, 6, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 8, 7, 0, 0, 0, 0, time.UTC)
+	data := testCandles(from.Add(13*time.Hour+30*time.Minute).Unix(), 2)
+	data[1].Timestamp = data[0].Timestamp + 86400
+	data[0].Close, data[1].Close = 82, 82
+
+	provider := NewCachedCorporateActionProvider(NewEODHDProvider("token", server.URL), time.Hour)
+	actions, err := provider.GetCorporateActions("AAPL", from, to)
+	if err != nil {
+		t.Fatalf("GetCorporateActions should not give error; got: %s", err.Error())
+	}
+	location, _ := time.LoadLocation("America/New_York")
+	adjusted := AdjustPrices(data, actions, location)
+
+	// The paid 0.82 is 1% of the unsplit close, the split adjusted 0.205 would only be 0.25%
+	if math.Abs(adjusted[0].Close-81.18) > 1e-9 || adjusted[1].Close != 82 {
+		t.Errorf("Expected adjusted closes 81.18 and 82; got: %.4f and %.4f", adjusted[0].Close, adjusted[1].Close)
+	}
+}
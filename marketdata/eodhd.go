@@ -0,0 +1,188 @@
+package marketdata
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+const eodhdBaseURL = "https://eodhd.com/api"
+
+// Longest range EODHD returns in one intraday request for each interval
+var eodhdMaxRange = map[string]time.Duration{
+	"1m": 120 * 24 * time.Hour,
+	"5m": 600 * 24 * time.Hour,
+	"1h": 7200 * 24 * time.Hour,
+}
+
+// EODHDProvider fetches intraday data from the EODHD API
+type EODHDProvider struct {
+	apiToken string
+	baseURL  string
+	client   *eodhdClient
+}
+
+// NewEODHDProvider creates a provider for the EODHD API, baseURL can point to a stub server in tests
+// and defaults to the real API when empty
+func NewEODHDProvider(apiToken string, baseURL string) *EODHDProvider {
+	return NewEODHDProviderWithConfig(apiToken, baseURL, DefaultEODHDConfig())
+}
+
+// NewEODHDProviderWithConfig creates a provider for the EODHD API with its own timeouts, retries and limits
+func NewEODHDProviderWithConfig(apiToken string, baseURL string, config EODHDConfig) *EODHDProvider {
+	if baseURL == "" {
+		baseURL = eodhdBaseURL
+	}
+	return &EODHDProvider{
+		apiToken: apiToken,
+		baseURL:  baseURL,
+		client:   newEODHDClient(config),
+	}
+}
+
+// Quota returns the use of the daily EODHD API calls made by this provider since the process started
+func (p *EODHDProvider) Quota() QuotaStatus {
+	return p.client.Quota()
+}
+
+// GetIntradayData fetches the default window of EODHD when the request has no from time. Otherwise
+// the range is split into the chunks EODHD allows for the interval and the results are merged.
+func (p *EODHDProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	defer utils.MeasureTime(time.Now(), "EODHDProvider.GetIntradayData")
+
+	if p.apiToken == "" {
+		return nil, fmt.Errorf("%w: API token is not set", ErrUnauthorized)
+	}
+
+	symbol, err := ParseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	intradayData := []models.IntradayData{}
+	if request.From.IsZero() {
+		intradayData, err = p.fetchRange(symbol, request)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if request.To.IsZero() {
+			request.To = time.Now()
+		}
+		for _, chunk := range splitRange(request, eodhdMaxRange[request.interval()]) {
+			data, err := p.fetchRange(symbol, chunk)
+			if err != nil {
+				return nil, err
+			}
+			intradayData = append(intradayData, data...)
+		}
+		intradayData = filterRange(sortAndDeduplicate(intradayData), request)
+	}
+
+	// Daily bars are already one per session, intraday data also holds pre- and post-market trading
+	if request.interval() == IntervalDaily {
+		return intradayData, nil
+	}
+	return FilterIntradayData(intradayData, symbol.Exchange)
+}
+
+// Splits the from/to range of a request into chunks of at most maxRange, a zero maxRange keeps it whole
+func splitRange(request DataRequest, maxRange time.Duration) []DataRequest {
+	if maxRange <= 0 {
+		return []DataRequest{request}
+	}
+
+	chunks := []DataRequest{}
+	for from := request.From; from.Before(request.To); from = from.Add(maxRange) {
+		chunk := request
+		chunk.From = from
+		chunk.To = from.Add(maxRange)
+		if chunk.To.After(request.To) {
+			chunk.To = request.To
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, request)
+	}
+	return chunks
+}
+
+func (p *EODHDProvider) fetchRange(symbol Symbol, request DataRequest) ([]models.IntradayData, error) {
+	if request.interval() == IntervalDaily {
+		return p.fetchDaily(symbol, request)
+	}
+
+	query := url.Values{}
+	query.Set("interval", request.interval())
+	query.Set("api_token", p.apiToken)
+	query.Set("fmt", "json")
+	if !request.From.IsZero() {
+		query.Set("from", strconv.FormatInt(request.From.Unix(), 10))
+	}
+	if !request.To.IsZero() {
+		query.Set("to", strconv.FormatInt(request.To.Unix(), 10))
+	}
+
+	var intradayData []models.IntradayData
+	err := p.get(fmt.Sprintf("%s/intraday/%s?%s", p.baseURL, symbol.EODHDCode(), query.Encode()), &intradayData)
+	if err != nil {
+		return nil, err
+	}
+
+	return intradayData, nil
+}
+
+// Daily bar of the EODHD end of day endpoint
+type eodhdDailyBar struct {
+	Date   string  `json:"date"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume int     `json:"volume"`
+}
+
+// Fetches daily bars from the end of day endpoint, each bar is stamped with the market open of its day
+func (p *EODHDProvider) fetchDaily(symbol Symbol, request DataRequest) ([]models.IntradayData, error) {
+	query := url.Values{}
+	query.Set("period", "d")
+	query.Set("api_token", p.apiToken)
+	query.Set("fmt", "json")
+	if !request.From.IsZero() {
+		query.Set("from", request.From.UTC().Format("2006-01-02"))
+	}
+	if !request.To.IsZero() {
+		query.Set("to", request.To.UTC().Format("2006-01-02"))
+	}
+
+	var bars []eodhdDailyBar
+	err := p.get(fmt.Sprintf("%s/eod/%s?%s", p.baseURL, symbol.EODHDCode(), query.Encode()), &bars)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := symbol.Exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	dailyData := make([]models.IntradayData, 0, len(bars))
+	for _, bar := range bars {
+		day, err := time.ParseInLocation("2006-01-02", bar.Date, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s in daily data: %v", bar.Date, err)
+		}
+		open := at(day, symbol.Exchange.Sessions[0].Open)
+		dailyData = append(dailyData, newCandle(open, bar.Open, bar.High, bar.Low, bar.Close, float64(bar.Volume)))
+	}
+
+	return dailyData, nil
+}
+
+func (p *EODHDProvider) get(requestURL string, result interface{}) error {
+	return p.client.get(requestURL, result)
+}
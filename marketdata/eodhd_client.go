@@ -0,0 +1,272 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors of the market data providers, wrapped with details so they can be matched with errors.Is
+var (
+	ErrUnknownSymbol = errors.New("unknown symbol")
+	ErrUnauthorized  = errors.New("market data API rejected the API token")
+	ErrQuotaExceeded = errors.New("market data API quota exceeded")
+	ErrUpstreamDown  = errors.New("market data API unavailable")
+)
+
+// EODHDConfig tunes the EODHD client
+type EODHDConfig struct {
+	Timeout           time.Duration // Per request
+	MaxRetries        int           // Retries of rate limited, failed and timed out requests
+	BaseBackoff       time.Duration // Wait before the first retry, doubled for each retry
+	MaxBackoff        time.Duration
+	RequestsPerSecond float64 // Rate of the token bucket limiting requests
+	Burst             int     // Requests allowed at once when the bucket is full
+	DailyLimit        int     // API calls allowed per day, intraday requests cost 5 calls
+}
+
+// DefaultEODHDConfig stays within the EODHD limit of 1000 requests per minute
+func DefaultEODHDConfig() EODHDConfig {
+	return EODHDConfig{
+		Timeout:           30 * time.Second,
+		MaxRetries:        3,
+		BaseBackoff:       500 * time.Millisecond,
+		MaxBackoff:        10 * time.Second,
+		RequestsPerSecond: 10,
+		Burst:             10,
+		DailyLimit:        100000,
+	}
+}
+
+// API calls counted by EODHD for each request
+const (
+	eodhdIntradayCost = 5
+	eodhdRequestCost  = 1
+)
+
+// QuotaStatus is the use of the daily API calls. It only counts the calls of this process since it
+// started, kept in memory, so calls of other instances or from before a restart aren't included.
+type QuotaStatus struct {
+	DailyLimit int       `json:"daily_limit"`
+	Used       int       `json:"used"`
+	Remaining  int       `json:"remaining"`
+	ResetsAt   time.Time `json:"resets_at"`
+}
+
+// tokenBucket lets requests through at a steady rate, with bursts up to its capacity
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+	sleep    func(time.Duration)
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:     rate,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// Wait blocks until a token is available and takes it
+func (b *tokenBucket) Wait() {
+	if b.rate <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.sleep(wait)
+		b.tokens = 1
+		b.last = b.last.Add(wait)
+	}
+	b.tokens--
+}
+
+// QuotaTracker reports the use of the daily API calls of a market data provider
+type QuotaTracker interface {
+	Quota() QuotaStatus
+}
+
+// eodhdClient sends requests to EODHD with timeouts, rate limiting, retries and quota tracking
+type eodhdClient struct {
+	http    *http.Client
+	config  EODHDConfig
+	limiter *tokenBucket
+	sleep   func(time.Duration)
+	now     func() time.Time
+
+	mu       sync.Mutex
+	quotaDay string
+	used     int
+}
+
+func newEODHDClient(config EODHDConfig) *eodhdClient {
+	return &eodhdClient{
+		http:    &http.Client{Timeout: config.Timeout},
+		config:  config,
+		limiter: newTokenBucket(config.RequestsPerSecond, config.Burst),
+		sleep:   time.Sleep,
+		now:     time.Now,
+	}
+}
+
+// Quota returns the use of the daily API calls, which reset at midnight UTC
+func (c *eodhdClient) Quota() QuotaStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetDay()
+	today, _ := time.Parse(calendarDayLayout, c.quotaDay)
+	return QuotaStatus{
+		DailyLimit: c.config.DailyLimit,
+		Used:       c.used,
+		Remaining:  max(c.config.DailyLimit-c.used, 0),
+		ResetsAt:   today.AddDate(0, 0, 1),
+	}
+}
+
+func (c *eodhdClient) resetDay() {
+	day := c.now().UTC().Format(calendarDayLayout)
+	if day != c.quotaDay {
+		c.quotaDay = day
+		c.used = 0
+	}
+}
+
+// Takes the calls of a request from the daily quota, fails when they aren't left
+func (c *eodhdClient) reserve(cost int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetDay()
+	if c.config.DailyLimit > 0 && c.used+cost > c.config.DailyLimit {
+		return fmt.Errorf("%w: %d of %d daily API calls used", ErrQuotaExceeded, c.used, c.config.DailyLimit)
+	}
+	c.used += cost
+	if remaining := c.config.DailyLimit - c.used; c.config.DailyLimit > 0 && remaining < c.config.DailyLimit/10 && remaining+cost >= c.config.DailyLimit/10 {
+		log.Printf("Warning: %d of %d daily EODHD API calls left", remaining, c.config.DailyLimit)
+	}
+	return nil
+}
+
+// get fetches the URL and decodes the JSON response into result
+func (c *eodhdClient) get(requestURL string, result interface{}) error {
+	cost := eodhdRequestCost
+	if strings.Contains(requestURL, "/intraday/") {
+		cost = eodhdIntradayCost
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			c.sleep(c.backoff(attempt, lastErr))
+		}
+		// EODHD bills every attempt, retries included
+		if err := c.reserve(cost); err != nil {
+			return err
+		}
+		c.limiter.Wait()
+
+		retry, err := c.do(requestURL, result)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+		log.Printf("EODHD request failed, attempt %d of %d: %v", attempt+1, c.config.MaxRetries+1, err)
+	}
+	return lastErr
+}
+
+// Error of a response that can be retried, with the wait asked for by the server
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Doubles the wait for each retry, or waits as long as the server asked for
+func (c *eodhdClient) backoff(attempt int, err error) time.Duration {
+	var retryable *retryableError
+	if errors.As(err, &retryable) && retryable.retryAfter > 0 {
+		return min(retryable.retryAfter, c.config.MaxBackoff)
+	}
+	return min(c.config.BaseBackoff<<(attempt-1), c.config.MaxBackoff)
+}
+
+// Sends one request and reports whether a failure can be retried
+func (c *eodhdClient) do(requestURL string, result interface{}) (bool, error) {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("User-Agent", "Go-http-client/1.1")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Timeouts and connection errors, without the URL holding the API token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return true, &retryableError{err: fmt.Errorf("%w: %v", ErrUpstreamDown, err)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return true, &retryableError{
+			err:        fmt.Errorf("%w: rate limited, status code: %d", ErrQuotaExceeded, resp.StatusCode),
+			retryAfter: time.Duration(retryAfter) * time.Second,
+		}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true, &retryableError{err: fmt.Errorf("%w: status code: %d", ErrUpstreamDown, resp.StatusCode)}
+	case resp.StatusCode == http.StatusNotFound:
+		return false, fmt.Errorf("%w: status code: %d", ErrUnknownSymbol, resp.StatusCode)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return false, fmt.Errorf("%w: status code: %d", ErrUnauthorized, resp.StatusCode)
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return false, fmt.Errorf("%w: status code: %d", ErrQuotaExceeded, resp.StatusCode)
+	default:
+		return false, fmt.Errorf("failed to fetch data, status code: %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	return false, nil
+}
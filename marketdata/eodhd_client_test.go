@@ -0,0 +1,163 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Client that records its waits instead of sleeping
+func testEODHDClient(config EODHDConfig) (*eodhdClient, *[]time.Duration) {
+	waits := []time.Duration{}
+	client := newEODHDClient(config)
+	client.sleep = func(d time.Duration) { waits = append(waits, d) }
+	client.limiter.sleep = client.sleep
+	return client, &waits
+}
+
+func TestEODHDClientRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 2:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			json.NewEncoder(w).Encode(testCandles(marketOpen, 3))
+		}
+	}))
+	defer server.Close()
+
+	client, waits := testEODHDClient(DefaultEODHDConfig())
+	var result []any
+	err := client.get(server.URL+"/intraday/AAPL.US", &result)
+	if err != nil {
+		t.Fatalf("get should not give error; got: %s", err.Error())
+	}
+	if len(result) != 3 || attempts != 3 {
+		t.Errorf("Expected 3 candles after 3 attempts; got: %d after %d", len(result), attempts)
+	}
+	if len(*waits) != 2 || (*waits)[0] != 500*time.Millisecond || (*waits)[1] != 2*time.Second {
+		t.Errorf("Expected waits of 500ms and the 2s of Retry-After; got: %v", *waits)
+	}
+	if quota := client.Quota(); quota.Used != 3*eodhdIntradayCost {
+		t.Errorf("Expected every attempt to be counted; got: %d calls used", quota.Used)
+	}
+}
+
+func TestEODHDClientGivesUpAfterRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, _ := testEODHDClient(DefaultEODHDConfig())
+	var result []any
+	err := client.get(server.URL+"/eod/AAPL.US", &result)
+	if !errors.Is(err, ErrUpstreamDown) {
+		t.Errorf("Expected ErrUpstreamDown; got: %v", err)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts; got: %d", attempts)
+	}
+
+	// Retries stop when the quota runs out
+	attempts = 0
+	config := DefaultEODHDConfig()
+	config.DailyLimit = 2
+	client, _ = testEODHDClient(config)
+	err = client.get(server.URL+"/eod/AAPL.US", &result)
+	if !errors.Is(err, ErrQuotaExceeded) || attempts != 2 {
+		t.Errorf("Expected ErrQuotaExceeded after 2 attempts; got: %v after %d", err, attempts)
+	}
+}
+
+func TestEODHDClientErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrUnknownSymbol},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusPaymentRequired, ErrQuotaExceeded},
+	}
+
+	for _, test := range tests {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(test.status)
+		}))
+
+		client, _ := testEODHDClient(DefaultEODHDConfig())
+		var result []any
+		err := client.get(server.URL+"/eod/AAPL.US", &result)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Expected %v for status %d; got: %v", test.want, test.status, err)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected no retries for status %d; got: %d attempts", test.status, attempts)
+		}
+		server.Close()
+	}
+}
+
+func TestEODHDClientDailyQuota(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]any{})
+	}))
+	defer server.Close()
+
+	config := DefaultEODHDConfig()
+	config.DailyLimit = 7
+	client, _ := testEODHDClient(config)
+	now := time.Date(2025, 6, 18, 23, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+
+	var result []any
+	if err := client.get(server.URL+"/intraday/AAPL.US", &result); err != nil {
+		t.Fatalf("get should not give error; got: %s", err.Error())
+	}
+	if err := client.get(server.URL+"/intraday/AAPL.US", &result); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded for the second intraday request; got: %v", err)
+	}
+	if err := client.get(server.URL+"/eod/AAPL.US", &result); err != nil {
+		t.Errorf("get should not give error within the quota; got: %s", err.Error())
+	}
+	if quota := client.Quota(); quota.Used != 6 || quota.Remaining != 1 {
+		t.Errorf("Expected 6 used and 1 remaining; got: %+v", quota)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if quota := client.Quota(); quota.Used != 0 {
+		t.Errorf("Expected the quota to reset at midnight UTC; got: %+v", quota)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC)
+	waited := time.Duration(0)
+	bucket := newTokenBucket(2, 2)
+	bucket.last = now
+	bucket.now = func() time.Time { return now }
+	bucket.sleep = func(d time.Duration) {
+		waited += d
+		now = now.Add(d)
+	}
+
+	for i := 0; i < 4; i++ {
+		bucket.Wait()
+	}
+	// The burst of 2 goes through, the next 2 requests wait half a second each
+	if waited != time.Second {
+		t.Errorf("Expected a wait of 1s; got: %v", waited)
+	}
+}
@@ -0,0 +1,164 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// EODHDStub is a local server answering intraday requests like EODHD for the tests. It rejects ranges longer than EODHD allows and records the requests it gets.
+type EODHDStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	candles  map[string][]models.IntradayData
+	requests []DataRequest
+}
+
+// NewEODHDStub starts a stub serving the candles of each symbol, daily bars are resampled from them, point NewEODHDProvider to its URL
+func NewEODHDStub(candles map[string][]models.IntradayData) *EODHDStub {
+	stub := &EODHDStub{candles: make(map[string][]models.IntradayData)}
+	for symbol, data := range candles {
+		stub.candles[stubKey(symbol)] = sortAndDeduplicate(append([]models.IntradayData{}, data...))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/intraday/", stub.handleIntraday)
+	mux.HandleFunc("/eod/", stub.handleDaily)
+	mux.HandleFunc("/exchange-symbol-list/", stub.handleSymbolList)
+	stub.Server = httptest.NewServer(mux)
+	return stub
+}
+
+// Requests returns the requests received so far
+func (s *EODHDStub) Requests() []DataRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]DataRequest{}, s.requests...)
+}
+
+func (s *EODHDStub) handleIntraday(w http.ResponseWriter, r *http.Request) {
+	symbol := stubKey(strings.TrimPrefix(r.URL.Path, "/intraday/"))
+	if r.URL.Query().Get("api_token") == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	request := DataRequest{Symbol: symbol, Interval: r.URL.Query().Get("interval")}
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &request.From}, {"to", &request.To}} {
+		if value := r.URL.Query().Get(param.name); value != "" {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid "+param.name, http.StatusBadRequest)
+				return
+			}
+			*param.value = time.Unix(seconds, 0)
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	data, exists := s.candles[symbol]
+	s.mu.Unlock()
+
+	if !exists {
+		http.Error(w, "Ticker not found", http.StatusNotFound)
+		return
+	}
+
+	maxRange, supported := eodhdMaxRange[request.interval()]
+	if !supported {
+		http.Error(w, "Invalid interval", http.StatusUnprocessableEntity)
+		return
+	}
+	if !request.From.IsZero() && !request.To.IsZero() && request.To.Sub(request.From) > maxRange {
+		http.Error(w, "Range is too long for the interval", http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(filterRange(data, request))
+}
+
+func (s *EODHDStub) handleDaily(w http.ResponseWriter, r *http.Request) {
+	symbol := stubKey(strings.TrimPrefix(r.URL.Path, "/eod/"))
+	if r.URL.Query().Get("api_token") == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	request := DataRequest{Symbol: symbol, Interval: IntervalDaily}
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	data, exists := s.candles[symbol]
+	s.mu.Unlock()
+
+	if !exists {
+		http.Error(w, "Ticker not found", http.StatusNotFound)
+		return
+	}
+
+	parsed, _ := ParseSymbol(symbol)
+	location, err := parsed.Exchange.Location()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	daily, err := Resample(data, IntervalDaily, parsed.Exchange)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bars := []eodhdDailyBar{}
+	for _, candle := range daily {
+		date := time.Unix(candle.Timestamp, 0).In(location).Format("2006-01-02")
+		if (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+		bars = append(bars, eodhdDailyBar{Date: date, Open: candle.Open, High: candle.High, Low: candle.Low, Close: candle.Close, Volume: candle.Volume})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bars)
+}
+
+// Lists the symbols of the exchange that the stub has candles for
+func (s *EODHDStub) handleSymbolList(w http.ResponseWriter, r *http.Request) {
+	exchange, exists := LookupExchange(strings.TrimPrefix(r.URL.Path, "/exchange-symbol-list/"))
+	if r.URL.Query().Get("api_token") == "" || !exists {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	listed := []eodhdSymbol{}
+	for key := range s.candles {
+		if symbol, err := ParseSymbol(key); err == nil && symbol.Exchange.Code == exchange.Code {
+			listed = append(listed, eodhdSymbol{Code: symbol.Ticker, Name: symbol.Ticker, Type: "Common Stock"})
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listed)
+}
+
+// Candles are kept by symbol as used in the API, so AAPL and AAPL.US are the same
+func stubKey(symbol string) string {
+	parsed, err := ParseSymbol(symbol)
+	if err != nil {
+		return strings.ToUpper(symbol)
+	}
+	return parsed.String()
+}
@@ -0,0 +1,121 @@
+package marketdata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Session is a regular trading session, as offsets from midnight in the exchange time zone
+type Session struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// Exchange describes where a symbol trades. Code is the EODHD exchange code used as the symbol
+// suffix, like OL in EQNR.OL. Exchanges with a lunch break have one session before and one after.
+// Exchanges without a calendar are open every weekday.
+type Exchange struct {
+	Code     string
+	Name     string
+	Timezone string
+	Sessions []Session
+	Calendar MarketCalendar
+}
+
+// Exchange of symbols without a suffix
+const DefaultExchange = "US"
+
+func clock(hour, minute int) time.Duration {
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+}
+
+var exchanges = map[string]Exchange{
+	"US":    {Code: "US", Name: "US exchanges", Timezone: "America/New_York", Sessions: []Session{{clock(9, 30), clock(16, 0)}}, Calendar: nyseCalendar},
+	"TO":    {Code: "TO", Name: "Toronto Stock Exchange", Timezone: "America/Toronto", Sessions: []Session{{clock(9, 30), clock(16, 0)}}},
+	"OL":    {Code: "OL", Name: "Oslo Børs", Timezone: "Europe/Oslo", Sessions: []Session{{clock(9, 0), clock(16, 20)}}, Calendar: osloCalendar},
+	"ST":    {Code: "ST", Name: "Nasdaq Stockholm", Timezone: "Europe/Stockholm", Sessions: []Session{{clock(9, 0), clock(17, 30)}}},
+	"CO":    {Code: "CO", Name: "Nasdaq Copenhagen", Timezone: "Europe/Copenhagen", Sessions: []Session{{clock(9, 0), clock(17, 0)}}},
+	"LSE":   {Code: "LSE", Name: "London Stock Exchange", Timezone: "Europe/London", Sessions: []Session{{clock(8, 0), clock(16, 30)}}},
+	"XETRA": {Code: "XETRA", Name: "Xetra", Timezone: "Europe/Berlin", Sessions: []Session{{clock(9, 0), clock(17, 30)}}},
+	"PA":    {Code: "PA", Name: "Euronext Paris", Timezone: "Europe/Paris", Sessions: []Session{{clock(9, 0), clock(17, 30)}}},
+	"HK":    {Code: "HK", Name: "Hong Kong Stock Exchange", Timezone: "Asia/Hong_Kong", Sessions: []Session{{clock(9, 30), clock(12, 0)}, {clock(13, 0), clock(16, 0)}}},
+	"SHG":   {Code: "SHG", Name: "Shanghai Stock Exchange", Timezone: "Asia/Shanghai", Sessions: []Session{{clock(9, 30), clock(11, 30)}, {clock(13, 0), clock(15, 0)}}},
+}
+
+// LookupExchange returns the exchange of an EODHD exchange code
+func LookupExchange(code string) (Exchange, bool) {
+	exchange, exists := exchanges[strings.ToUpper(code)]
+	return exchange, exists
+}
+
+// Location loads the time zone of the exchange
+func (e Exchange) Location() (*time.Location, error) {
+	location, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone of exchange %s: %v", e.Code, err)
+	}
+	return location, nil
+}
+
+// Wall clock time since midnight, which also holds on days when daylight saving time changes
+func timeOfDay(local time.Time) time.Duration {
+	return clock(local.Hour(), local.Minute()) + time.Duration(local.Second())*time.Second
+}
+
+// at returns the time of an offset from midnight on the date of day, in the time zone of day
+func at(day time.Time, offset time.Duration) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, int(offset.Hours()), int(offset.Minutes())%60, 0, 0, day.Location())
+}
+
+// InSession reports whether a time in the exchange time zone is within a regular session,
+// taking weekends, holidays and half days into account
+func (e Exchange) InSession(local time.Time) bool {
+	sinceMidnight := timeOfDay(local)
+	for _, session := range e.SessionsOn(local) {
+		if sinceMidnight >= session.Open && sinceMidnight < session.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// Symbol is a ticker on an exchange
+type Symbol struct {
+	Ticker   string
+	Exchange Exchange
+}
+
+// ParseSymbol splits a symbol like EQNR.OL into ticker and exchange, symbols without a known
+// exchange suffix trade in the US
+func ParseSymbol(symbol string) (Symbol, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return Symbol{}, fmt.Errorf("missing symbol")
+	}
+
+	ticker, code := symbol, DefaultExchange
+	if index := strings.LastIndex(symbol, "."); index > 0 {
+		ticker, code = symbol[:index], symbol[index+1:]
+	}
+
+	exchange, exists := LookupExchange(code)
+	if !exists {
+		return Symbol{}, fmt.Errorf("unknown exchange %s of symbol %s", code, symbol)
+	}
+	return Symbol{Ticker: ticker, Exchange: exchange}, nil
+}
+
+// String returns the symbol as used in the API, US symbols without suffix
+func (s Symbol) String() string {
+	if s.Exchange.Code == DefaultExchange {
+		return s.Ticker
+	}
+	return s.Ticker + "." + s.Exchange.Code
+}
+
+// EODHDCode returns the symbol with the EODHD exchange suffix, like AAPL.US
+func (s Symbol) EODHDCode() string {
+	return s.Ticker + "." + s.Exchange.Code
+}
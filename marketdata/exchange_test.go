@@ -0,0 +1,68 @@
+package marketdata
+
+import (
+	"testing"
+	"trend-hencher-api/models"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		symbol   string
+		ticker   string
+		exchange string
+		eodhd    string
+	}{
+		{"AAPL", "AAPL", "US", "AAPL.US"},
+		{"aapl.us", "AAPL", "US", "AAPL.US"},
+		{"EQNR.OL", "EQNR", "OL", "EQNR.OL"},
+		{"VOD.LSE", "VOD", "LSE", "VOD.LSE"},
+	}
+
+	for _, test := range tests {
+		symbol, err := ParseSymbol(test.symbol)
+		if err != nil {
+			t.Errorf("ParseSymbol should not give error for %s; got: %s", test.symbol, err.Error())
+			continue
+		}
+		if symbol.Ticker != test.ticker || symbol.Exchange.Code != test.exchange || symbol.EODHDCode() != test.eodhd {
+			t.Errorf("Unexpected symbol for %s: %+v", test.symbol, symbol)
+		}
+	}
+
+	if _, err := ParseSymbol("EQNR.XX"); err == nil {
+		t.Errorf("ParseSymbol should give error for an unknown exchange")
+	}
+}
+
+func TestFilterIntradayDataUsesExchangeSessions(t *testing.T) {
+	oslo, _ := LookupExchange("OL")
+	hongKong, _ := LookupExchange("HK")
+
+	// 2025-06-18 at 06:59, 07:00, 14:19 and 14:20 UTC
+	data := []models.IntradayData{
+		{Timestamp: 1750229940},
+		{Timestamp: 1750230000},
+		{Timestamp: 1750256340},
+		{Timestamp: 1750256400},
+	}
+
+	// Oslo is at UTC+2 in summer, the session is 09:00 to 16:20
+	filtered, err := FilterIntradayData(data, oslo)
+	if err != nil {
+		t.Fatalf("FilterIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(filtered) != 2 || filtered[0].Timestamp != 1750230000 || filtered[1].Timestamp != 1750256340 {
+		t.Errorf("Expected the candles at 09:00 and 16:19 Oslo time; got: %+v", filtered)
+	}
+
+	// Hong Kong is at UTC+8, so only the candles at 14:59 and 15:00 are in session.
+	// 04:30 UTC is 12:30, during the lunch break.
+	data = append(data, models.IntradayData{Timestamp: 1750221000})
+	filtered, err = FilterIntradayData(data, hongKong)
+	if err != nil {
+		t.Fatalf("FilterIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(filtered) != 2 || filtered[0].Timestamp != 1750229940 {
+		t.Errorf("Expected the candles at 14:59 and 15:00 Hong Kong time; got: %+v", filtered)
+	}
+}
@@ -0,0 +1,313 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"trend-hencher-api/models"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+)
+
+// Timestamp formats besides Go time layouts
+const (
+	TimestampUnix      = "unix"    // Seconds since epoch
+	TimestampUnixMilli = "unix_ms" // Milliseconds since epoch
+)
+
+// ColumnMapping names the columns holding each candle field in a CSV or Parquet file
+type ColumnMapping struct {
+	Timestamp string `json:"timestamp"`
+	Open      string `json:"open"`
+	High      string `json:"high"`
+	Low       string `json:"low"`
+	Close     string `json:"close"`
+	Volume    string `json:"volume"`
+}
+
+// FileFormat describes how candles are stored in CSV and Parquet files from other vendors
+type FileFormat struct {
+	Columns ColumnMapping `json:"columns"`
+	// TimestampFormat is unix, unix_ms or a Go time layout like "2006-01-02 15:04:05"
+	TimestampFormat string `json:"timestamp_format"`
+	// Timezone of timestamps written without an offset, UTC when empty
+	Timezone string `json:"timezone"`
+}
+
+// DefaultFileFormat expects lowercase column names and unix timestamps
+func DefaultFileFormat() FileFormat {
+	return FileFormat{
+		Columns: ColumnMapping{
+			Timestamp: "timestamp",
+			Open:      "open",
+			High:      "high",
+			Low:       "low",
+			Close:     "close",
+			Volume:    "volume",
+		},
+		TimestampFormat: TimestampUnix,
+	}
+}
+
+// LoadFileFormat reads a file format from a JSON file. Missing fields keep their defaults.
+func LoadFileFormat(path string) (FileFormat, error) {
+	format := DefaultFileFormat()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return format, fmt.Errorf("failed to read file format: %v", err)
+	}
+	if err := json.Unmarshal(data, &format); err != nil {
+		return format, fmt.Errorf("failed to parse file format: %v", err)
+	}
+	return format, nil
+}
+
+func (f FileFormat) location() (*time.Location, error) {
+	if f.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(f.Timezone)
+}
+
+func (f FileFormat) parseTimestamp(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch f.TimestampFormat {
+	case TimestampUnix, "":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(int64(seconds), 0), nil
+	case TimestampUnixMilli:
+		milliseconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(milliseconds), nil
+	default:
+		return time.ParseInLocation(f.TimestampFormat, value, location)
+	}
+}
+
+// Builds a candle in the same shape as the EODHD intraday data, with the datetime in UTC
+func newCandle(timestamp time.Time, open, high, low, close, volume float64) models.IntradayData {
+	return models.IntradayData{
+		Timestamp: timestamp.Unix(),
+		Datetime:  timestamp.UTC().Format("2006-01-02 15:04:05"),
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     close,
+		Volume:    int(math.Round(volume)),
+	}
+}
+
+// ReadCSV reads candles from a CSV file with a header row
+func ReadCSV(reader io.Reader, format FileFormat) ([]models.IntradayData, error) {
+	location, err := format.location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %v", err)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+
+	columnIndex := make(map[string]int)
+	for i, name := range header {
+		columnIndex[strings.TrimSpace(name)] = i
+	}
+
+	columns := format.Columns
+	names := []string{columns.Timestamp, columns.Open, columns.High, columns.Low, columns.Close, columns.Volume}
+	indices := make([]int, len(names))
+	for i, name := range names {
+		index, exists := columnIndex[name]
+		if !exists {
+			return nil, fmt.Errorf("missing column %q in CSV header", name)
+		}
+		indices[i] = index
+	}
+
+	candles := []models.IntradayData{}
+	for line := 2; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %v", line, err)
+		}
+
+		timestamp, err := format.parseTimestamp(record[indices[0]], location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp on CSV line %d: %v", line, err)
+		}
+
+		values := make([]float64, len(indices)-1)
+		for i, index := range indices[1:] {
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(record[index]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s on CSV line %d: %v", names[i+1], line, err)
+			}
+		}
+
+		candles = append(candles, newCandle(timestamp, values[0], values[1], values[2], values[3], values[4]))
+	}
+
+	return candles, nil
+}
+
+// ReadParquet reads candles from a Parquet file. Timestamp columns can be Parquet timestamps,
+// integers in the unix format of the file format or strings.
+func ReadParquet(path string, format FileFormat) ([]models.IntradayData, error) {
+	location, err := format.location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %v", err)
+	}
+
+	parquetReader, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Parquet file: %v", err)
+	}
+	defer parquetReader.Close()
+
+	fileReader, err := pqarrow.NewFileReader(parquetReader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet file: %v", err)
+	}
+
+	table, err := fileReader.ReadTable(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet table: %v", err)
+	}
+	defer table.Release()
+
+	columns := format.Columns
+	timestamps, err := parquetTimestamps(table, columns.Timestamp, format, location)
+	if err != nil {
+		return nil, err
+	}
+
+	values := [][]float64{}
+	for _, name := range []string{columns.Open, columns.High, columns.Low, columns.Close, columns.Volume} {
+		column, err := parquetFloats(table, name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, column)
+	}
+
+	candles := make([]models.IntradayData, len(timestamps))
+	for i, timestamp := range timestamps {
+		candles[i] = newCandle(timestamp, values[0][i], values[1][i], values[2][i], values[3][i], values[4][i])
+	}
+
+	return candles, nil
+}
+
+func parquetColumn(table arrow.Table, name string) (*arrow.Column, error) {
+	indices := table.Schema().FieldIndices(name)
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("missing column %q in Parquet file", name)
+	}
+	return table.Column(indices[0]), nil
+}
+
+func parquetFloats(table arrow.Table, name string) ([]float64, error) {
+	column, err := parquetColumn(table, name)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, 0, column.Len())
+	for _, chunk := range column.Data().Chunks() {
+		for i := 0; i < chunk.Len(); i++ {
+			switch typed := chunk.(type) {
+			case *array.Float64:
+				values = append(values, typed.Value(i))
+			case *array.Float32:
+				values = append(values, float64(typed.Value(i)))
+			case *array.Int64:
+				values = append(values, float64(typed.Value(i)))
+			case *array.Int32:
+				values = append(values, float64(typed.Value(i)))
+			default:
+				return nil, fmt.Errorf("unsupported type %s for column %q", chunk.DataType(), name)
+			}
+		}
+	}
+	return values, nil
+}
+
+func parquetTimestamps(table arrow.Table, name string, format FileFormat, location *time.Location) ([]time.Time, error) {
+	column, err := parquetColumn(table, name)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]time.Time, 0, column.Len())
+	for _, chunk := range column.Data().Chunks() {
+		var toTime func(arrow.Timestamp) time.Time
+		if timestampType, isTimestamp := chunk.DataType().(*arrow.TimestampType); isTimestamp {
+			toTime, err = timestampType.GetToTimeFunc()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for i := 0; i < chunk.Len(); i++ {
+			var value string
+			switch typed := chunk.(type) {
+			case *array.Timestamp:
+				values = append(values, toTime(typed.Value(i)))
+				continue
+			case *array.Int64:
+				value = strconv.FormatInt(typed.Value(i), 10)
+			case *array.String:
+				value = typed.Value(i)
+			default:
+				return nil, fmt.Errorf("unsupported type %s for timestamp column %q", chunk.DataType(), name)
+			}
+
+			timestamp, err := format.parseTimestamp(value, location)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp in Parquet file: %v", err)
+			}
+			values = append(values, timestamp)
+		}
+	}
+	return values, nil
+}
+
+// ReadCandleFile reads a CSV or Parquet file depending on its extension
+func ReadCandleFile(path string, format FileFormat) ([]models.IntradayData, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadCSV(f, format)
+	case ".parquet":
+		return ReadParquet(path, format)
+	}
+	return nil, fmt.Errorf("unsupported candle file %s, expected .csv or .parquet", path)
+}
@@ -0,0 +1,161 @@
+package marketdata
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+)
+
+func TestReadCSVWithColumnMapping(t *testing.T) {
+	csvData := "Date,Open,High,Low,Close,Vol\n" +
+		"2025-06-18 09:30:00,100,101,99,100.5,1000\n" +
+		"2025-06-18 09:31:00,100.5,102,100,101.5,2000\n"
+
+	format := FileFormat{
+		Columns:         ColumnMapping{Timestamp: "Date", Open: "Open", High: "High", Low: "Low", Close: "Close", Volume: "Vol"},
+		TimestampFormat: "2006-01-02 15:04:05",
+		Timezone:        "America/New_York",
+	}
+
+	data, err := ReadCSV(strings.NewReader(csvData), format)
+	if err != nil {
+		t.Fatalf("ReadCSV should not give error; got: %s", err.Error())
+	}
+
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 candles; got: %d", len(data))
+	}
+	if data[0].Timestamp != marketOpen {
+		t.Errorf("Expected timestamp %d in UTC; got: %d", marketOpen, data[0].Timestamp)
+	}
+	if data[0].Datetime != "2025-06-18 13:30:00" {
+		t.Errorf("Expected UTC datetime; got: %s", data[0].Datetime)
+	}
+	if data[1].Close != 101.5 || data[1].Volume != 2000 {
+		t.Errorf("Unexpected second candle: %+v", data[1])
+	}
+}
+
+func TestReadCSVMissingColumn(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("timestamp,open,high,low,close\n"), DefaultFileFormat())
+	if err == nil {
+		t.Errorf("ReadCSV should give error for a missing volume column")
+	}
+}
+
+func TestReadParquet(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "timestamp", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+		{Name: "open", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "high", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "low", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "close", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "volume", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	for i := int64(0); i < 3; i++ {
+		builder.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp((marketOpen + i*60) * 1000))
+		builder.Field(1).(*array.Float64Builder).Append(100)
+		builder.Field(2).(*array.Float64Builder).Append(101)
+		builder.Field(3).(*array.Float64Builder).Append(99)
+		builder.Field(4).(*array.Float64Builder).Append(100 + float64(i))
+		builder.Field(5).(*array.Int64Builder).Append(1000)
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	table := array.NewTableFromRecords(schema, []arrow.Record{record})
+	defer table.Release()
+
+	directory := t.TempDir()
+	path := filepath.Join(directory, "AAPL", "2025.parquet")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pqarrow.WriteTable(table, f, 1024, nil, pqarrow.DefaultWriterProps()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ReadParquet(path, DefaultFileFormat())
+	if err != nil {
+		t.Fatalf("ReadParquet should not give error; got: %s", err.Error())
+	}
+	if len(data) != 3 || data[2].Timestamp != marketOpen+120 || data[2].Close != 102 {
+		t.Errorf("Unexpected Parquet candles: %+v", data)
+	}
+
+	provider := NewFileProvider(directory, DefaultFileFormat())
+	data, err = provider.GetIntradayData(DataRequest{Symbol: "AAPL"})
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 3 {
+		t.Errorf("Expected 3 candles from the file provider; got: %d", len(data))
+	}
+}
+
+func TestImportDirectory(t *testing.T) {
+	source := t.TempDir()
+	store := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(source, "msft"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	csvData := "timestamp,open,high,low,close,volume\n1750253460,1,2,1,2,10\n1750253400,1,2,1,1,10\n"
+	if err := os.WriteFile(filepath.Join(source, "msft", "june.csv"), []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ImportDirectory(source, store, DefaultFileFormat())
+	if err != nil {
+		t.Fatalf("ImportDirectory should not give error; got: %s", err.Error())
+	}
+	if len(results) != 1 || results[0].Symbol != "MSFT" || results[0].Candles != 2 {
+		t.Fatalf("Unexpected import results: %+v", results)
+	}
+
+	data, err := NewLocalProvider(store).GetIntradayData(DataRequest{Symbol: "MSFT"})
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 2 || data[0].Timestamp != marketOpen {
+		t.Errorf("Expected the imported candles sorted in the local store; got: %+v", data)
+	}
+}
+
+func TestImportDirectoryClash(t *testing.T) {
+	source := t.TempDir()
+	store := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(source, "msft"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	csvData := "timestamp,open,high,low,close,volume\n1750253400,1,2,1,1,10\n"
+	for _, name := range []string{"june.csv", "june.parquet"} {
+		if err := os.WriteFile(filepath.Join(source, "msft", name), []byte(csvData), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := ImportDirectory(source, store, DefaultFileFormat())
+	if err == nil || !strings.Contains(err.Error(), "june.json") {
+		t.Fatalf("ImportDirectory should give error naming june.json but got: %v", err)
+	}
+
+	// Nothing is written when a clash is found
+	if _, err := os.Stat(filepath.Join(store, "MSFT", "june.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected no imported file after a clash; got: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+// FileProvider reads candles from CSV and Parquet files exported from other vendors, stored in
+// one directory per symbol: <directory>/<SYMBOL>/*.csv|*.parquet. Like LocalProvider the files hold
+// 1 minute candles that are resampled to the requested interval.
+type FileProvider struct {
+	directory string
+	format    FileFormat
+}
+
+func NewFileProvider(directory string, format FileFormat) *FileProvider {
+	return &FileProvider{directory: directory, format: format}
+}
+
+func (p *FileProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	defer utils.MeasureTime(time.Now(), "FileProvider.GetIntradayData")
+
+	symbol, err := ParseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolDirectory := filepath.Join(p.directory, symbol.String())
+	files, err := candleFiles(symbolDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no CSV or Parquet data for symbol %s in %s", ErrUnknownSymbol, request.Symbol, symbolDirectory)
+	}
+
+	intradayData := []models.IntradayData{}
+	for _, file := range files {
+		fileData, err := ReadCandleFile(file, p.format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		intradayData = append(intradayData, fileData...)
+	}
+
+	intradayData = filterRange(sortAndDeduplicate(intradayData), request)
+
+	intradayData, err = FilterIntradayData(intradayData, symbol.Exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter data %v", err)
+	}
+
+	return Resample(intradayData, request.interval(), symbol.Exchange)
+}
+
+func candleFiles(directory string) ([]string, error) {
+	files := []string{}
+	for _, pattern := range []string{"*.csv", "*.parquet"} {
+		matches, err := filepath.Glob(filepath.Join(directory, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ImportResult summarizes one imported file
+type ImportResult struct {
+	Symbol  string
+	Source  string
+	Target  string
+	Candles int
+}
+
+// ImportDirectory converts every CSV and Parquet file in <source>/<SYMBOL>/ into JSON files in
+// the local candle store read by LocalProvider: <store>/<SYMBOL>/<file name>.json. Files that would
+// be written to the same JSON file, like X.csv and X.parquet, are an error and nothing is imported.
+func ImportDirectory(source, store string, format FileFormat) ([]ImportResult, error) {
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import directory: %v", err)
+	}
+
+	// Plan every import first, so a clash is found before anything is overwritten
+	type importJob struct {
+		file   string
+		symbol string
+	}
+	jobs := []importJob{}
+	sources := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		symbol := strings.ToUpper(entry.Name())
+
+		files, err := candleFiles(filepath.Join(source, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			target := importTarget(file, symbol, store)
+			if other, exists := sources[target]; exists {
+				return nil, fmt.Errorf("%s and %s would both be imported to %s", other, file, target)
+			}
+			sources[target] = file
+			jobs = append(jobs, importJob{file: file, symbol: symbol})
+		}
+	}
+
+	results := []ImportResult{}
+	for _, job := range jobs {
+		result, err := importFile(job.file, job.symbol, store, format)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// JSON file in the local candle store a CSV or Parquet file is imported to
+func importTarget(file, symbol, store string) string {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return filepath.Join(store, symbol, name+".json")
+}
+
+func importFile(file, symbol, store string, format FileFormat) (ImportResult, error) {
+	candles, err := ReadCandleFile(file, format)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to read %s: %v", file, err)
+	}
+	candles = sortAndDeduplicate(candles)
+
+	symbolDirectory := filepath.Join(store, symbol)
+	if err := os.MkdirAll(symbolDirectory, 0755); err != nil {
+		return ImportResult{}, fmt.Errorf("failed to create %s: %v", symbolDirectory, err)
+	}
+
+	target := importTarget(file, symbol, store)
+
+	data, err := json.Marshal(candles)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to marshal candles: %v", err)
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return ImportResult{}, fmt.Errorf("failed to write %s: %v", target, err)
+	}
+
+	return ImportResult{Symbol: symbol, Source: file, Target: target, Candles: len(candles)}, nil
+}
@@ -0,0 +1,139 @@
+package marketdata
+
+import (
+	"fmt"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Bar intervals, 1m/5m/1h are intraday candles and 1d is end of day data
+const (
+	Interval1Minute = "1m"
+	Interval5Minute = "5m"
+	Interval1Hour   = "1h"
+	IntervalDaily   = "1d"
+)
+
+// Length of a bar of each interval, a daily bar covers the whole session
+var intervalDurations = map[string]time.Duration{
+	Interval1Minute: time.Minute,
+	Interval5Minute: 5 * time.Minute,
+	Interval1Hour:   time.Hour,
+	IntervalDaily:   24 * time.Hour,
+}
+
+// Range fetched by the candle cache for requests without a from time
+var intervalLookback = map[string]time.Duration{
+	Interval1Minute: 7 * 24 * time.Hour,
+	Interval5Minute: 30 * 24 * time.Hour,
+	Interval1Hour:   180 * 24 * time.Hour,
+	IntervalDaily:   5 * 365 * 24 * time.Hour,
+}
+
+// WarmupStart returns the time far enough before from to fetch bars bars of the interval first, counting
+// the trading days of the exchange and assuming full sessions, so indicators are warmed up at from
+func WarmupStart(from time.Time, bars int, interval string, exchange Exchange) time.Time {
+	if from.IsZero() || bars <= 0 {
+		return from
+	}
+	duration, exists := intervalDurations[interval]
+	if !exists {
+		duration = intervalDurations[DefaultInterval]
+	}
+
+	sessionMinutes := time.Duration(0)
+	for _, session := range exchange.Sessions {
+		sessionMinutes += session.Close - session.Open
+	}
+	barsPerDay := max(int(sessionMinutes/duration), 1)
+	if duration >= intervalDurations[IntervalDaily] {
+		barsPerDay = 1
+	}
+
+	location, err := exchange.Location()
+	if err != nil {
+		location = time.UTC
+	}
+	days := (bars + barsPerDay - 1) / barsPerDay
+	start := at(from.In(location), 0)
+	for days > 0 {
+		start = start.AddDate(0, 0, -1)
+		if exchange.IsTradingDay(start) {
+			days--
+		}
+	}
+	return start
+}
+
+// ValidateInterval returns an error for intervals that aren't supported, empty means the default
+func ValidateInterval(interval string) error {
+	if interval == "" {
+		return nil
+	}
+	if _, exists := intervalDurations[interval]; !exists {
+		return fmt.Errorf("unsupported interval %s, expected 1m, 5m, 1h or 1d", interval)
+	}
+	return nil
+}
+
+// Resample aggregates 1 minute candles into bars of the interval. Intraday bars are aligned to the
+// open of the session in the time zone of the exchange, so the first hourly bar of a US day covers
+// 9:30 to 10:30 ET, and candles outside the sessions are aligned to midnight. Daily bars cover one
+// trading day in the time zone of the exchange. Each bar keeps the timestamp of its first candle.
+func Resample(data []models.IntradayData, interval string, exchange Exchange) ([]models.IntradayData, error) {
+	duration, exists := intervalDurations[interval]
+	if !exists || duration <= time.Minute {
+		return data, nil
+	}
+
+	location, err := exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := func(entry models.IntradayData) int64 {
+		local := time.Unix(entry.Timestamp, 0).In(location)
+		if interval == IntervalDaily {
+			year, month, day := local.Date()
+			return int64(year*10000 + int(month)*100 + day)
+		}
+		anchor := sessionAnchor(local, exchange).Unix()
+		seconds := int64(duration.Seconds())
+		return anchor + (entry.Timestamp-anchor)/seconds*seconds
+	}
+
+	resampled := []models.IntradayData{}
+	var current int64
+	for _, entry := range data {
+		key := bucket(entry)
+		if len(resampled) == 0 || key != current {
+			current = key
+			resampled = append(resampled, entry)
+			continue
+		}
+
+		bar := &resampled[len(resampled)-1]
+		if entry.High > bar.High {
+			bar.High = entry.High
+		}
+		if entry.Low < bar.Low {
+			bar.Low = entry.Low
+		}
+		bar.Close = entry.Close
+		bar.Volume += entry.Volume
+	}
+	return resampled, nil
+}
+
+// Open of the latest session that started at or before a time in the exchange time zone, or
+// midnight when no session has opened yet that day
+func sessionAnchor(local time.Time, exchange Exchange) time.Time {
+	anchor := time.Duration(0)
+	sinceMidnight := timeOfDay(local)
+	for _, session := range exchange.SessionsOn(local) {
+		if session.Open <= sinceMidnight && session.Open > anchor {
+			anchor = session.Open
+		}
+	}
+	return at(local, anchor)
+}
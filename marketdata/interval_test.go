@@ -0,0 +1,126 @@
+package marketdata
+
+import (
+	"testing"
+	"time"
+	"trend-hencher-api/models"
+)
+
+func TestResample(t *testing.T) {
+	data := testCandles(marketOpen, 10)
+	for i := range data {
+		data[i].Close = float64(100 + i)
+		data[i].High = float64(101 + i)
+	}
+
+	exchange, _ := LookupExchange("US")
+
+	bars, err := Resample(data, Interval5Minute, exchange)
+	if err != nil {
+		t.Fatalf("Resample should not give error; got: %s", err.Error())
+	}
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 bars of 5 minutes; got: %d", len(bars))
+	}
+	if bars[0].Timestamp != marketOpen || bars[0].Close != 104 || bars[0].High != 105 || bars[0].Volume != 5000 {
+		t.Errorf("Unexpected first bar: %+v", bars[0])
+	}
+
+	daily, _ := Resample(append(data, testCandles(marketOpen+86400, 5)...), IntervalDaily, exchange)
+	if len(daily) != 2 {
+		t.Errorf("Expected 2 daily bars; got: %d", len(daily))
+	}
+}
+
+func TestResampleHourAlignedToSessionOpen(t *testing.T) {
+	exchange, _ := LookupExchange("US")
+
+	// A full session from 9:30 to 16:00 ET gives 7 hourly bars starting at 9:30, 10:30...
+	bars, err := Resample(testCandles(marketOpen, 390), Interval1Hour, exchange)
+	if err != nil {
+		t.Fatalf("Resample should not give error; got: %s", err.Error())
+	}
+	if len(bars) != 7 {
+		t.Fatalf("Expected 7 hourly bars; got: %d", len(bars))
+	}
+	for i, bar := range bars[:6] {
+		if bar.Timestamp != marketOpen+int64(i)*3600 || bar.Volume != 60000 {
+			t.Errorf("Expected hourly bar %d at the open plus %d hours with 60 candles; got: %+v", i, i, bar)
+		}
+	}
+	if bars[6].Volume != 30000 {
+		t.Errorf("Expected the last bar to hold the last half hour; got: %+v", bars[6])
+	}
+
+	// Hong Kong starts again after lunch at 13:00, 2025-06-18 05:00 UTC
+	hongKong, _ := LookupExchange("HK")
+	afternoon := int64(1750222800)
+	morning := testCandles(afternoon-90*60, 30)
+	bars, _ = Resample(append(morning, testCandles(afternoon, 90)...), Interval1Hour, hongKong)
+	if len(bars) != 3 || bars[0].Timestamp != afternoon-90*60 || bars[1].Timestamp != afternoon || bars[2].Timestamp != afternoon+3600 {
+		t.Errorf("Expected hourly bars of the afternoon session to start at 13:00; got: %+v", bars)
+	}
+}
+
+func TestWarmupStart(t *testing.T) {
+	us, _ := LookupExchange("US")
+	location, _ := us.Location()
+	from := time.Date(2025, 6, 23, 9, 30, 0, 0, location)
+
+	tests := []struct {
+		bars     int
+		interval string
+		expected time.Time
+	}{
+		// 3 trading days back from Monday, skipping the weekend and Juneteenth
+		{3, IntervalDaily, time.Date(2025, 6, 17, 0, 0, 0, 0, location)},
+		// 390 minute bars a day, so 400 need 2 days
+		{400, Interval1Minute, time.Date(2025, 6, 18, 0, 0, 0, 0, location)},
+		{0, Interval1Minute, from},
+	}
+
+	for _, test := range tests {
+		if start := WarmupStart(from, test.bars, test.interval, us); !start.Equal(test.expected) {
+			t.Errorf("Expected %d bars of %s to start at %s; got: %s", test.bars, test.interval, test.expected, start)
+		}
+	}
+	if start := WarmupStart(time.Time{}, 10, IntervalDaily, us); !start.IsZero() {
+		t.Errorf("Expected no start without a from time; got: %s", start)
+	}
+}
+
+func TestValidateInterval(t *testing.T) {
+	for _, interval := range []string{"", "1m", "5m", "1h", "1d"} {
+		if err := ValidateInterval(interval); err != nil {
+			t.Errorf("ValidateInterval should not give error for %q; got: %s", interval, err.Error())
+		}
+	}
+	if ValidateInterval("15m") == nil {
+		t.Errorf("ValidateInterval should give error for 15m")
+	}
+}
+
+func TestEODHDProviderDailyBars(t *testing.T) {
+	candles := []models.IntradayData{}
+	for day := int64(0); day < 3; day++ {
+		candles = append(candles, testCandles(marketOpen+day*86400, 390)...)
+	}
+	stub := NewEODHDStub(map[string][]models.IntradayData{"AAPL": candles})
+	defer stub.Close()
+
+	request := DataRequest{Symbol: "AAPL", Interval: IntervalDaily, From: time.Unix(marketOpen, 0), To: time.Unix(marketOpen+2*86400, 0)}
+	data, err := NewEODHDProvider("token", stub.URL).GetIntradayData(request)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 daily bars; got: %d", len(data))
+	}
+	if data[0].Timestamp != marketOpen || data[0].Volume != 390000 {
+		t.Errorf("Expected the daily bar at the market open with the volume of the day; got: %+v", data[0])
+	}
+	if len(stub.Requests()) != 1 {
+		t.Errorf("Expected one request to the end of day endpoint; got: %d", len(stub.Requests()))
+	}
+}
@@ -0,0 +1,67 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+// LocalProvider reads candles from JSON files in EODHD format, stored in one directory per
+// symbol: <directory>/<SYMBOL>/*.json. The files of a symbol are merged into one series of 1 minute
+// candles, which is resampled when another interval is requested.
+type LocalProvider struct {
+	directory string
+}
+
+func NewLocalProvider(directory string) *LocalProvider {
+	return &LocalProvider{directory: directory}
+}
+
+func (p *LocalProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	defer utils.MeasureTime(time.Now(), "LocalProvider.GetIntradayData")
+
+	symbol, err := ParseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolDirectory := filepath.Join(p.directory, symbol.String())
+	files, err := filepath.Glob(filepath.Join(symbolDirectory, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local files: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no local data for symbol %s in %s", ErrUnknownSymbol, request.Symbol, symbolDirectory)
+	}
+	sort.Strings(files)
+
+	intradayData := []models.IntradayData{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read local file: %v", err)
+		}
+
+		var fileData []models.IntradayData
+		err = json.Unmarshal(data, &fileData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse local JSON file %s: %v", file, err)
+		}
+		intradayData = append(intradayData, fileData...)
+	}
+
+	intradayData = filterRange(sortAndDeduplicate(intradayData), request)
+
+	// Set correct timezone for intraday data:
+	intradayData, err = FilterIntradayData(intradayData, symbol.Exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter data %v", err)
+	}
+
+	return Resample(intradayData, request.interval(), symbol.Exchange)
+}
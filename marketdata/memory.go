@@ -0,0 +1,38 @@
+package marketdata
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"trend-hencher-api/models"
+)
+
+// MemoryProvider serves candles kept in memory, mainly for tests. The candles are returned as
+// they were set, whatever interval is requested.
+type MemoryProvider struct {
+	mu   sync.RWMutex
+	data map[string][]models.IntradayData
+}
+
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{data: make(map[string][]models.IntradayData)}
+}
+
+// Set replaces the candles of a symbol
+func (p *MemoryProvider) Set(symbol string, data []models.IntradayData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.data[strings.ToUpper(symbol)] = sortAndDeduplicate(append([]models.IntradayData{}, data...))
+}
+
+func (p *MemoryProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	data, exists := p.data[strings.ToUpper(request.Symbol)]
+	if !exists {
+		return nil, fmt.Errorf("%w: no data for symbol %s", ErrUnknownSymbol, request.Symbol)
+	}
+	return filterRange(data, request), nil
+}
@@ -0,0 +1,61 @@
+package marketdata
+
+import (
+	"sort"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Interval used when a request doesn't choose one
+const DefaultInterval = "1m"
+
+// DataRequest selects the candles to fetch. Zero From/To times mean no limit.
+type DataRequest struct {
+	Symbol   string
+	Interval string
+	From     time.Time
+	To       time.Time
+}
+
+// MarketDataProvider fetches candles for a symbol, each backend (EODHD, local files, memory)
+// implements it so the handlers don't need to know where the data comes from
+type MarketDataProvider interface {
+	GetIntradayData(request DataRequest) ([]models.IntradayData, error)
+}
+
+func (r DataRequest) interval() string {
+	if r.Interval == "" {
+		return DefaultInterval
+	}
+	return r.Interval
+}
+
+// Keeps the candles within the from/to range of the request
+func filterRange(data []models.IntradayData, request DataRequest) []models.IntradayData {
+	filtered := []models.IntradayData{}
+	for _, entry := range data {
+		if !request.From.IsZero() && entry.Timestamp < request.From.Unix() {
+			continue
+		}
+		if !request.To.IsZero() && entry.Timestamp > request.To.Unix() {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// Sorts the candles by time and removes duplicated timestamps, keeping the last one
+func sortAndDeduplicate(data []models.IntradayData) []models.IntradayData {
+	sort.SliceStable(data, func(i, j int) bool { return data[i].Timestamp < data[j].Timestamp })
+
+	deduplicated := []models.IntradayData{}
+	for _, entry := range data {
+		if len(deduplicated) > 0 && deduplicated[len(deduplicated)-1].Timestamp == entry.Timestamp {
+			deduplicated[len(deduplicated)-1] = entry
+			continue
+		}
+		deduplicated = append(deduplicated, entry)
+	}
+	return deduplicated
+}
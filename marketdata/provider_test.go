@@ -0,0 +1,147 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// 2025-06-18 at 13:30 UTC (9:30 ET)
+const marketOpen = int64(1750253400)
+
+func testCandles(start int64, count int) []models.IntradayData {
+	data := make([]models.IntradayData, count)
+	for i := range data {
+		data[i] = models.IntradayData{Timestamp: start + int64(i)*60, Open: 100, High: 101, Low: 99, Close: 100, Volume: 1000}
+	}
+	return data
+}
+
+func writeLocalFile(t *testing.T, directory, symbol, name string, data []models.IntradayData) {
+	t.Helper()
+
+	symbolDirectory := filepath.Join(directory, symbol)
+	if err := os.MkdirAll(symbolDirectory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(symbolDirectory, name), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalProvider(t *testing.T) {
+	directory := t.TempDir()
+	writeLocalFile(t, directory, "AAPL", "1.json", testCandles(marketOpen, 10))
+	writeLocalFile(t, directory, "AAPL", "2.json", testCandles(marketOpen+5*60, 10))
+
+	provider := NewLocalProvider(directory)
+
+	data, err := provider.GetIntradayData(DataRequest{Symbol: "aapl"})
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	// The files overlap by 5 candles
+	if len(data) != 15 {
+		t.Errorf("Expected 15 candles from the merged files; got: %d", len(data))
+	}
+}
+
+func TestLocalProviderWithDifferentSymbols(t *testing.T) {
+	directory := t.TempDir()
+	writeLocalFile(t, directory, "AAPL", "1.json", testCandles(marketOpen, 10))
+
+	_, err := NewLocalProvider(directory).GetIntradayData(DataRequest{Symbol: "MSFT"})
+	if err == nil {
+		t.Errorf("GetIntradayData should give error for a symbol without data but didn't get any")
+	}
+}
+
+func TestMemoryProviderRange(t *testing.T) {
+	provider := NewMemoryProvider()
+	provider.Set("AAPL", testCandles(marketOpen, 10))
+
+	data, err := provider.GetIntradayData(DataRequest{
+		Symbol: "AAPL",
+		From:   time.Unix(marketOpen+2*60, 0),
+		To:     time.Unix(marketOpen+5*60, 0),
+	})
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 4 {
+		t.Errorf("Expected 4 candles within the range; got: %d", len(data))
+	}
+}
+
+func TestEODHDProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/intraday/AAPL.US" || r.URL.Query().Get("interval") != "1m" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(testCandles(marketOpen, 3))
+	}))
+	defer server.Close()
+
+	data, err := NewEODHDProvider("token", server.URL).GetIntradayData(DataRequest{Symbol: "AAPL"})
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	if len(data) != 3 {
+		t.Errorf("Expected 3 candles; got: %d", len(data))
+	}
+}
+
+func TestEODHDProviderPaginatesLongRanges(t *testing.T) {
+	// Two candles on each trading day for 300 days, an hour after the open so they are in session
+	// in summer and winter time
+	us, _ := LookupExchange("US")
+	candles := []models.IntradayData{}
+	for day := int64(0); day < 300; day++ {
+		if us.IsTradingDay(time.Unix(marketOpen+day*86400, 0).UTC()) {
+			candles = append(candles, testCandles(marketOpen+3600+day*86400, 2)...)
+		}
+	}
+	stub := NewEODHDStub(map[string][]models.IntradayData{"AAPL": candles})
+	defer stub.Close()
+
+	request := DataRequest{
+		Symbol: "AAPL",
+		From:   time.Unix(marketOpen+3600, 0),
+		To:     time.Unix(marketOpen+3600+299*86400+60, 0),
+	}
+	data, err := NewEODHDProvider("token", stub.URL).GetIntradayData(request)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	// Chunks share their boundary candle, which is only kept once
+	if len(data) != len(candles) {
+		t.Errorf("Expected %d candles; got: %d", len(candles), len(data))
+	}
+	if len(stub.Requests()) != 3 {
+		t.Errorf("Expected 3 chunks of at most 120 days; got: %d", len(stub.Requests()))
+	}
+	for i := 1; i < len(data); i++ {
+		if data[i].Timestamp <= data[i-1].Timestamp {
+			t.Fatalf("Expected sorted candles without duplicates at %d", i)
+		}
+	}
+}
+
+func TestEODHDProviderWithoutToken(t *testing.T) {
+	_, err := NewEODHDProvider("", "").GetIntradayData(DataRequest{Symbol: "AAPL"})
+	if err == nil {
+		t.Errorf("GetIntradayData should give error but didn't get any")
+	}
+}
@@ -0,0 +1,208 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Repair policies for bad candles and gaps
+const (
+	RepairIgnore = "ignore" // Keep gaps as they are
+	RepairDrop   = "drop"   // Remove bad candles
+	RepairFill   = "fill"   // Replace bad candles and fill gaps with flat candles at the previous close
+	RepairReject = "reject" // Fail the run
+)
+
+// ErrDataQuality is returned when a reject policy finds problems in the data
+var ErrDataQuality = errors.New("data quality check failed")
+
+// QualityConfig chooses how problems in the candles are handled. Duplicates and out of order
+// candles are always repaired by sorting and keeping the last candle of each timestamp.
+type QualityConfig struct {
+	Invalid string // Policy for invalid OHLC, zero or negative prices and spikes: drop, fill or reject
+	Gaps    string // Policy for missing bars within sessions: ignore, fill or reject
+	// A close moving more than this ratio from the previous close and back on the next bar is a spike
+	SpikeThreshold float64
+}
+
+func DefaultQualityConfig() QualityConfig {
+	return QualityConfig{
+		Invalid:        RepairDrop,
+		Gaps:           RepairIgnore,
+		SpikeThreshold: 0.1,
+	}
+}
+
+// Validate returns an error for unknown policies
+func (c QualityConfig) Validate() error {
+	switch c.Invalid {
+	case RepairDrop, RepairFill, RepairReject:
+	default:
+		return fmt.Errorf("invalid quality policy %q, expected drop, fill or reject", c.Invalid)
+	}
+	switch c.Gaps {
+	case RepairIgnore, RepairFill, RepairReject:
+	default:
+		return fmt.Errorf("invalid gap policy %q, expected ignore, fill or reject", c.Gaps)
+	}
+	return nil
+}
+
+// CheckQuality validates the candles of the interval traded on the exchange and repairs them by
+// the policies of the config. The report is returned also when a reject policy fails the run.
+func CheckQuality(data []models.IntradayData, exchange Exchange, interval string, config QualityConfig) ([]models.IntradayData, models.QualityReport, error) {
+	report := models.QualityReport{Candles: len(data)}
+
+	for i := 1; i < len(data); i++ {
+		if data[i].Timestamp < data[i-1].Timestamp {
+			report.OutOfOrder++
+		}
+	}
+	sorted := append([]models.IntradayData{}, data...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	deduplicated := sortAndDeduplicate(sorted)
+	report.Duplicates = len(sorted) - len(deduplicated)
+
+	// Invalid candles first, so spikes are measured against valid prices
+	bad := make([]bool, len(deduplicated))
+	for i, candle := range deduplicated {
+		switch {
+		case candle.Open <= 0 || candle.High <= 0 || candle.Low <= 0 || candle.Close <= 0:
+			report.NonPositivePrices++
+			bad[i] = true
+		case candle.High < candle.Low || outside(candle.Open, candle.Low, candle.High) || outside(candle.Close, candle.Low, candle.High):
+			report.InvalidOHLC++
+			bad[i] = true
+		}
+	}
+	report.Spikes = markSpikes(deduplicated, bad, config.SpikeThreshold)
+
+	if report.NonPositivePrices+report.InvalidOHLC+report.Spikes > 0 && config.Invalid == RepairReject {
+		report.Rejected = true
+		return nil, report, fmt.Errorf("%w: %d invalid candles and %d spikes", ErrDataQuality, report.NonPositivePrices+report.InvalidOHLC, report.Spikes)
+	}
+
+	repaired := make([]models.IntradayData, 0, len(deduplicated))
+	for i, candle := range deduplicated {
+		if !bad[i] {
+			repaired = append(repaired, candle)
+			continue
+		}
+		if config.Invalid == RepairFill && len(repaired) > 0 {
+			repaired = append(repaired, flatCandle(repaired[len(repaired)-1], candle.Timestamp))
+			report.Filled++
+			continue
+		}
+		report.Dropped++
+	}
+
+	// Missing bars are always counted, the policy only decides what is done about them
+	filled, missing, err := fillGaps(repaired, exchange, interval)
+	if err != nil {
+		return nil, report, err
+	}
+	report.MissingBars = missing
+
+	switch {
+	case missing > 0 && config.Gaps == RepairReject:
+		report.Rejected = true
+		return nil, report, fmt.Errorf("%w: %d missing bars", ErrDataQuality, missing)
+	case config.Gaps == RepairFill:
+		report.Filled += missing
+		return filled, report, nil
+	}
+	return repaired, report, nil
+}
+
+func outside(price, low, high float64) bool {
+	return price < low || price > high
+}
+
+// Marks closes that jump more than threshold from the previous valid close and come back on the next
+// valid bar, which is a bad tick rather than a move of the market
+func markSpikes(data []models.IntradayData, bad []bool, threshold float64) int {
+	if threshold <= 0 {
+		return 0
+	}
+
+	valid := []int{}
+	for i := range data {
+		if !bad[i] {
+			valid = append(valid, i)
+		}
+	}
+
+	spikes := 0
+	for j := 1; j+1 < len(valid); j++ {
+		previous, current, next := data[valid[j-1]].Close, data[valid[j]].Close, data[valid[j+1]].Close
+		jump := current/previous - 1
+		back := next/current - 1
+		if math.Abs(jump) > threshold && math.Abs(back) > threshold && jump*back < 0 && math.Abs(next/previous-1) <= threshold {
+			bad[valid[j]] = true
+			spikes++
+		}
+	}
+	return spikes
+}
+
+// A candle without trading at the close of the previous candle
+func flatCandle(previous models.IntradayData, timestamp int64) models.IntradayData {
+	candle := models.IntradayData{
+		Timestamp: timestamp,
+		GmtOffset: previous.GmtOffset,
+		Open:      previous.Close,
+		High:      previous.Close,
+		Low:       previous.Close,
+		Close:     previous.Close,
+	}
+	candle.Datetime = time.Unix(timestamp+int64(previous.GmtOffset), 0).UTC().Format("2006-01-02 15:04:05")
+	return candle
+}
+
+// Inserts flat candles for the bars missing within the sessions of the exchange and returns how many
+// were missing. Daily bars are missing on trading days without a bar.
+func fillGaps(data []models.IntradayData, exchange Exchange, interval string) ([]models.IntradayData, int, error) {
+	location, err := exchange.Location()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	duration, exists := intervalDurations[interval]
+	if !exists {
+		duration = intervalDurations[DefaultInterval]
+	}
+
+	filled := make([]models.IntradayData, 0, len(data))
+	missing := 0
+	for i, candle := range data {
+		if i > 0 {
+			previous := data[i-1]
+			next := time.Unix(previous.Timestamp, 0).In(location)
+			for {
+				if interval == IntervalDaily {
+					next = next.AddDate(0, 0, 1)
+				} else {
+					next = next.Add(duration)
+				}
+				if next.Unix() >= candle.Timestamp {
+					break
+				}
+
+				expected := exchange.InSession(next)
+				if interval == IntervalDaily {
+					expected = exchange.IsTradingDay(next)
+				}
+				if expected {
+					filled = append(filled, flatCandle(previous, next.Unix()))
+					missing++
+				}
+			}
+		}
+		filled = append(filled, candle)
+	}
+	return filled, missing, nil
+}
@@ -0,0 +1,79 @@
+package marketdata
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckQualityRepairsCandles(t *testing.T) {
+	us, _ := LookupExchange("US")
+
+	data := testCandles(marketOpen, 10)
+	data[2], data[3] = data[3], data[2] // Out of order
+	data = append(data, data[9])        // Duplicate
+	data[4].Low = 102                   // Low above high
+	data[5].Close = 0                   // Zero price
+	data[7].Close, data[7].High = 130, 130
+	data = append(data[:8], data[9:]...) // Missing minute
+
+	repaired, report, err := CheckQuality(data, us, Interval1Minute, DefaultQualityConfig())
+	if err != nil {
+		t.Fatalf("CheckQuality should not give error; got: %s", err.Error())
+	}
+
+	if report.OutOfOrder != 1 || report.Duplicates != 1 || report.InvalidOHLC != 1 || report.NonPositivePrices != 1 || report.Spikes != 1 {
+		t.Errorf("Unexpected quality report: %+v", report)
+	}
+	if report.Dropped != 3 || len(repaired) != 6 {
+		t.Errorf("Expected 3 dropped candles and 6 left; got: %d dropped, %d left", report.Dropped, len(repaired))
+	}
+	// Gaps are ignored by default but still counted, the dropped candles leave gaps as well
+	if report.MissingBars != 4 || report.Filled != 0 {
+		t.Errorf("Expected 4 missing bars that aren't filled; got: %d missing, %d filled", report.MissingBars, report.Filled)
+	}
+	for i := 1; i < len(repaired); i++ {
+		if repaired[i].Timestamp <= repaired[i-1].Timestamp {
+			t.Errorf("Expected sorted candles at %d", i)
+		}
+	}
+}
+
+func TestCheckQualityFillsGaps(t *testing.T) {
+	us, _ := LookupExchange("US")
+
+	data := testCandles(marketOpen, 5)
+	data = append(data[:1], data[4:]...)
+	data[0].Close = 100.5
+	data[0].High = 101
+
+	config := DefaultQualityConfig()
+	config.Gaps = RepairFill
+	repaired, report, err := CheckQuality(data, us, Interval1Minute, config)
+	if err != nil {
+		t.Fatalf("CheckQuality should not give error; got: %s", err.Error())
+	}
+
+	if report.MissingBars != 3 || report.Filled != 3 || len(repaired) != 5 {
+		t.Fatalf("Expected 3 filled minutes; got: %+v with %d candles", report, len(repaired))
+	}
+	if repaired[1].Timestamp != marketOpen+60 || repaired[1].Open != 100.5 || repaired[1].Close != 100.5 || repaired[1].Volume != 0 {
+		t.Errorf("Expected a flat candle at the previous close; got: %+v", repaired[1])
+	}
+}
+
+func TestCheckQualityRejectsRun(t *testing.T) {
+	us, _ := LookupExchange("US")
+
+	data := testCandles(marketOpen, 5)
+	data[2].High = 98
+
+	config := DefaultQualityConfig()
+	config.Invalid = RepairReject
+	_, report, err := CheckQuality(data, us, Interval1Minute, config)
+	if !errors.Is(err, ErrDataQuality) {
+		t.Fatalf("CheckQuality should give a data quality error; got: %v", err)
+	}
+	if !report.Rejected || report.InvalidOHLC != 1 {
+		t.Errorf("Expected a rejected report with 1 invalid candle; got: %+v", report)
+	}
+}
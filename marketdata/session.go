@@ -0,0 +1,105 @@
+package marketdata
+
+import (
+	"fmt"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// FilterIntradayData keeps the candles within the regular sessions of the exchange, which skips
+// weekends, holidays and the end of half days, and converts them to Oslo time
+func FilterIntradayData(intradayData []models.IntradayData, exchange Exchange) ([]models.IntradayData, error) {
+	exchangeLocation, err := exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the Oslo timezone
+	osloLocation, err := time.LoadLocation("Europe/Oslo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Oslo timezone: %v", err)
+	}
+
+	filteredData := []models.IntradayData{}
+
+	for _, data := range intradayData {
+		// Check if the time falls within the sessions of the exchange
+		if !exchange.InSession(time.Unix(data.Timestamp, 0).In(exchangeLocation)) {
+			continue
+		}
+
+		// Convert the timestamp to Oslo time
+		osloTime := time.Unix(data.Timestamp, 0).In(osloLocation)
+
+		// Oslo is at +1 hour in standard time and +2 hours in daylight saving time
+		_, gmtOffset := osloTime.Zone()
+
+		// Add the data to the filtered list, but adjust the timestamp and datetime for Oslo time
+		filteredData = append(filteredData, models.IntradayData{
+			Timestamp: osloTime.Unix(), // Convert back to Unix timestamp if needed
+			GmtOffset: gmtOffset,
+			Datetime:  osloTime.Format("2006-01-02 15:04:05"),
+			Open:      data.Open,
+			High:      data.High,
+			Low:       data.Low,
+			Close:     data.Close,
+			Volume:    data.Volume,
+		})
+	}
+
+	return filteredData, nil
+}
+
+// SessionStarts marks the bars that start a trading session, used to reset session indicators like
+// the VWAP: the first bar of each day in the exchange time zone and, for data with pre-market
+// candles, the first bar at or after the open. A lunch break doesn't start a new session.
+func SessionStarts(data []models.IntradayData, exchange Exchange) ([]bool, error) {
+	location, err := exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	starts := make([]bool, len(data))
+	var previousDay string
+	var previousOpen bool
+	for i, entry := range data {
+		local := time.Unix(entry.Timestamp, 0).In(location)
+		day := local.Format(calendarDayLayout)
+
+		sessions := exchange.SessionsOn(local)
+		open := len(sessions) > 0 && timeOfDay(local) >= sessions[0].Open
+
+		starts[i] = i == 0 || day != previousDay || (open && !previousOpen)
+		previousDay, previousOpen = day, open
+	}
+	return starts, nil
+}
+
+// SessionEnds marks the bars that end a trading session: bars of the interval reaching the close
+// of the exchange on that day, including early closes, and the last bar before a gap to another day.
+func SessionEnds(data []models.IntradayData, exchange Exchange, interval string) ([]bool, error) {
+	location, err := exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	duration, exists := intervalDurations[interval]
+	if !exists {
+		duration = intervalDurations[DefaultInterval]
+	}
+
+	ends := make([]bool, len(data))
+	for i, entry := range data {
+		local := time.Unix(entry.Timestamp, 0).In(location)
+		if close, isTradingDay := exchange.SessionClose(local); isTradingDay && !local.Add(duration).Before(close) {
+			ends[i] = true
+			continue
+		}
+
+		if i+1 < len(data) {
+			next := time.Unix(data[i+1].Timestamp, 0).In(location)
+			ends[i] = next.Format(calendarDayLayout) != local.Format(calendarDayLayout)
+		}
+	}
+	return ends, nil
+}
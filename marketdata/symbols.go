@@ -0,0 +1,281 @@
+package marketdata
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long an exchange symbol list is used before it's loaded again
+const DefaultSymbolListTTL = 24 * time.Hour
+
+// SymbolInfo is a ticker listed on an exchange
+type SymbolInfo struct {
+	Symbol   string `json:"symbol"` // Ticker with the exchange suffix, as accepted by /checkMarket
+	Ticker   string `json:"ticker"`
+	Exchange string `json:"exchange"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Currency string `json:"currency"`
+}
+
+// SymbolListProvider lists the tickers of an exchange
+type SymbolListProvider interface {
+	GetSymbols(exchange Exchange) ([]SymbolInfo, error)
+}
+
+// Ticker of the EODHD exchange symbol list endpoint
+type eodhdSymbol struct {
+	Code     string `json:"Code"`
+	Name     string `json:"Name"`
+	Type     string `json:"Type"`
+	Currency string `json:"Currency"`
+}
+
+// GetSymbols fetches the tickers of the exchange from the EODHD exchange symbol list
+func (p *EODHDProvider) GetSymbols(exchange Exchange) ([]SymbolInfo, error) {
+	if p.apiToken == "" {
+		return nil, fmt.Errorf("%w: API token is not set", ErrUnauthorized)
+	}
+
+	query := url.Values{}
+	query.Set("api_token", p.apiToken)
+	query.Set("fmt", "json")
+
+	var listed []eodhdSymbol
+	err := p.get(fmt.Sprintf("%s/exchange-symbol-list/%s?%s", p.baseURL, exchange.Code, query.Encode()), &listed)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]SymbolInfo, 0, len(listed))
+	for _, s := range listed {
+		symbols = append(symbols, newSymbolInfo(s.Code, exchange, s.Name, s.Type, s.Currency))
+	}
+	return symbols, nil
+}
+
+func newSymbolInfo(ticker string, exchange Exchange, name, kind, currency string) SymbolInfo {
+	ticker = strings.ToUpper(ticker)
+	return SymbolInfo{
+		Symbol:   Symbol{Ticker: ticker, Exchange: exchange}.String(),
+		Ticker:   ticker,
+		Exchange: exchange.Code,
+		Name:     name,
+		Type:     kind,
+		Currency: currency,
+	}
+}
+
+// DirectorySymbolList lists the symbols with data in a directory of the local and file providers,
+// which store one directory per symbol: <directory>/<SYMBOL>/
+type DirectorySymbolList struct {
+	directory string
+}
+
+func NewDirectorySymbolList(directory string) *DirectorySymbolList {
+	return &DirectorySymbolList{directory: directory}
+}
+
+func (l *DirectorySymbolList) GetSymbols(exchange Exchange) ([]SymbolInfo, error) {
+	entries, err := os.ReadDir(l.directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list symbols in %s: %v", l.directory, err)
+	}
+
+	symbols := []SymbolInfo{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		symbol, err := ParseSymbol(entry.Name())
+		if err != nil || symbol.Exchange.Code != exchange.Code {
+			continue
+		}
+		symbols = append(symbols, newSymbolInfo(symbol.Ticker, exchange, "", "", ""))
+	}
+	return symbols, nil
+}
+
+// Symbol list of an exchange, indexed by ticker
+type symbolList struct {
+	symbols  []SymbolInfo
+	byTicker map[string]SymbolInfo
+	loadedAt time.Time
+}
+
+// Symbol list of one exchange, locked while it's loaded so concurrent callers wait for the same load
+type exchangeSymbols struct {
+	mu   sync.Mutex
+	list *symbolList // Nil until loaded
+}
+
+// SymbolDirectory validates and searches symbols with the symbol lists of the exchanges, which are
+// loaded when first needed and kept for the TTL
+type SymbolDirectory struct {
+	provider SymbolListProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	exchanges map[string]*exchangeSymbols
+}
+
+func NewSymbolDirectory(provider SymbolListProvider, ttl time.Duration) *SymbolDirectory {
+	return &SymbolDirectory{
+		provider:  provider,
+		ttl:       ttl,
+		now:       time.Now,
+		exchanges: map[string]*exchangeSymbols{},
+	}
+}
+
+func (d *SymbolDirectory) entry(code string) *exchangeSymbols {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, exists := d.exchanges[code]
+	if !exists {
+		entry = &exchangeSymbols{}
+		d.exchanges[code] = entry
+	}
+	return entry
+}
+
+// Returns the symbol list of the exchange, loading it when missing or expired. When loading fails
+// an expired list is used rather than failing. Only callers of the same exchange wait for a load.
+func (d *SymbolDirectory) list(exchange Exchange) (*symbolList, error) {
+	entry := d.entry(exchange.Code)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	cached := entry.list
+	if cached != nil && d.now().Sub(cached.loadedAt) < d.ttl {
+		return cached, nil
+	}
+
+	symbols, err := d.provider.GetSymbols(exchange)
+	if err != nil {
+		if cached != nil {
+			log.Printf("Warning: failed to reload symbols of %s, using the list from %s: %v", exchange.Code, cached.loadedAt.Format(time.RFC3339), err)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("failed to load symbols of %s: %w", exchange.Code, err)
+	}
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Ticker < symbols[j].Ticker })
+	loaded := &symbolList{symbols: symbols, byTicker: make(map[string]SymbolInfo, len(symbols)), loadedAt: d.now()}
+	for _, symbol := range symbols {
+		loaded.byTicker[symbol.Ticker] = symbol
+	}
+	entry.list = loaded
+	log.Printf("Loaded %d symbols of %s", len(symbols), exchange.Code)
+	return loaded, nil
+}
+
+// Symbol lists that are loaded by exchange code, skipping those being loaded rather than waiting
+func (d *SymbolDirectory) loaded() map[string]*symbolList {
+	d.mu.Lock()
+	entries := make(map[string]*exchangeSymbols, len(d.exchanges))
+	for code, entry := range d.exchanges {
+		entries[code] = entry
+	}
+	d.mu.Unlock()
+
+	lists := map[string]*symbolList{}
+	for code, entry := range entries {
+		if !entry.mu.TryLock() {
+			continue
+		}
+		if entry.list != nil {
+			lists[code] = entry.list
+		}
+		entry.mu.Unlock()
+	}
+	return lists
+}
+
+// Lookup returns the listing of the symbol, or ErrUnknownSymbol when the exchange doesn't list it
+func (d *SymbolDirectory) Lookup(symbol Symbol) (SymbolInfo, error) {
+	list, err := d.list(symbol.Exchange)
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+
+	info, exists := list.byTicker[symbol.Ticker]
+	if !exists {
+		return SymbolInfo{}, fmt.Errorf("%w: %s is not listed on %s", ErrUnknownSymbol, symbol, symbol.Exchange.Code)
+	}
+	return info, nil
+}
+
+// Search finds up to limit symbols whose ticker or name contains the query, on the given exchanges,
+// or when none are given on the exchanges whose list is already loaded, so a search never downloads
+// every list. Exact tickers come first, then tickers starting with the query and then the rest, each
+// by ticker.
+func (d *SymbolDirectory) Search(query string, exchangeCodes []string, limit int) ([]SymbolInfo, error) {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	if query == "" {
+		return nil, fmt.Errorf("missing query")
+	}
+
+	lists := []*symbolList{}
+	if len(exchangeCodes) == 0 {
+		for _, list := range d.loaded() {
+			lists = append(lists, list)
+		}
+	}
+	for _, code := range exchangeCodes {
+		exchange, exists := LookupExchange(code)
+		if !exists {
+			return nil, fmt.Errorf("unknown exchange %s", code)
+		}
+		list, err := d.list(exchange)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	type match struct {
+		info SymbolInfo
+		rank int
+	}
+	matches := []match{}
+	for _, list := range lists {
+		for _, info := range list.symbols {
+			switch {
+			case info.Ticker == query:
+				matches = append(matches, match{info, 0})
+			case strings.HasPrefix(info.Ticker, query):
+				matches = append(matches, match{info, 1})
+			case strings.Contains(info.Ticker, query) || strings.Contains(strings.ToUpper(info.Name), query):
+				matches = append(matches, match{info, 2})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		if matches[i].info.Ticker != matches[j].info.Ticker {
+			return matches[i].info.Ticker < matches[j].info.Ticker
+		}
+		return matches[i].info.Symbol < matches[j].info.Symbol
+	})
+
+	results := []SymbolInfo{}
+	for _, m := range matches {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		results = append(results, m.info)
+	}
+	return results, nil
+}
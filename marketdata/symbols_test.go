@@ -0,0 +1,174 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Symbol list provider serving fixed lists, failing when failing is set
+type staticSymbolList struct {
+	symbols map[string][]SymbolInfo
+	calls   int
+	failing bool
+}
+
+func (l *staticSymbolList) GetSymbols(exchange Exchange) ([]SymbolInfo, error) {
+	l.calls++
+	if l.failing {
+		return nil, fmt.Errorf("%w: status code: 503", ErrUpstreamDown)
+	}
+	return l.symbols[exchange.Code], nil
+}
+
+func testSymbolList() *staticSymbolList {
+	us, _ := LookupExchange("US")
+	oslo, _ := LookupExchange("OL")
+	return &staticSymbolList{symbols: map[string][]SymbolInfo{
+		"US": {
+			newSymbolInfo("AAPL", us, "Apple Inc", "Common Stock", "USD"),
+			newSymbolInfo("AAP", us, "Advance Auto Parts Inc", "Common Stock", "USD"),
+			newSymbolInfo("MSFT", us, "Microsoft Corporation", "Common Stock", "USD"),
+		},
+		"OL": {
+			newSymbolInfo("EQNR", oslo, "Equinor ASA", "Common Stock", "NOK"),
+		},
+	}}
+}
+
+func TestSymbolDirectoryLookup(t *testing.T) {
+	directory := NewSymbolDirectory(testSymbolList(), DefaultSymbolListTTL)
+
+	symbol, _ := ParseSymbol("eqnr.ol")
+	info, err := directory.Lookup(symbol)
+	if err != nil {
+		t.Fatalf("Lookup should not give error; got: %s", err.Error())
+	}
+	if info.Symbol != "EQNR.OL" || info.Name != "Equinor ASA" {
+		t.Errorf("Expected EQNR.OL Equinor ASA; got: %s %s", info.Symbol, info.Name)
+	}
+
+	symbol, _ = ParseSymbol("EQNR")
+	if _, err := directory.Lookup(symbol); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("Expected ErrUnknownSymbol for EQNR on US; got: %v", err)
+	}
+}
+
+func TestSymbolDirectorySearch(t *testing.T) {
+	directory := NewSymbolDirectory(testSymbolList(), DefaultSymbolListTTL)
+
+	results, err := directory.Search("aap", []string{"US"}, 10)
+	if err != nil {
+		t.Fatalf("Search should not give error; got: %s", err.Error())
+	}
+	if len(results) != 2 || results[0].Ticker != "AAP" || results[1].Ticker != "AAPL" {
+		t.Errorf("Expected AAP before AAPL; got: %+v", results)
+	}
+
+	results, _ = directory.Search("equinor", []string{"OL"}, 10)
+	if len(results) != 1 || results[0].Symbol != "EQNR.OL" {
+		t.Errorf("Expected EQNR.OL by name; got: %+v", results)
+	}
+}
+
+func TestSymbolDirectorySearchLoadedExchanges(t *testing.T) {
+	provider := testSymbolList()
+	directory := NewSymbolDirectory(provider, DefaultSymbolListTTL)
+
+	// Without exchanges only the lists already loaded are searched
+	results, err := directory.Search("a", nil, 10)
+	if err != nil || len(results) != 0 || provider.calls != 0 {
+		t.Errorf("Expected no results and no loads before any list is loaded; got: %+v, %d loads, %v", results, provider.calls, err)
+	}
+
+	symbol, _ := ParseSymbol("EQNR.OL")
+	directory.Lookup(symbol)
+	results, _ = directory.Search("a", nil, 10)
+	if len(results) != 1 || results[0].Symbol != "EQNR.OL" || provider.calls != 1 {
+		t.Errorf("Expected only EQNR.OL from the loaded list; got: %+v, %d loads", results, provider.calls)
+	}
+}
+
+// Symbol list provider that blocks loading the US list until released
+type blockingSymbolList struct {
+	*staticSymbolList
+	started chan struct{}
+	release chan struct{}
+}
+
+func (l *blockingSymbolList) GetSymbols(exchange Exchange) ([]SymbolInfo, error) {
+	if exchange.Code == "US" {
+		close(l.started)
+		<-l.release
+	}
+	return l.symbols[exchange.Code], nil
+}
+
+func TestSymbolDirectoryLoadsExchangesIndependently(t *testing.T) {
+	provider := &blockingSymbolList{staticSymbolList: testSymbolList(), started: make(chan struct{}), release: make(chan struct{})}
+	directory := NewSymbolDirectory(provider, DefaultSymbolListTTL)
+
+	done := make(chan error)
+	go func() {
+		symbol, _ := ParseSymbol("AAPL")
+		_, err := directory.Lookup(symbol)
+		done <- err
+	}()
+	<-provider.started
+
+	// Another exchange and a search of the loaded lists don't wait for the US list
+	symbol, _ := ParseSymbol("EQNR.OL")
+	if _, err := directory.Lookup(symbol); err != nil {
+		t.Errorf("Lookup should not give error while another exchange loads; got: %s", err.Error())
+	}
+	if results, _ := directory.Search("eqnr", nil, 10); len(results) != 1 {
+		t.Errorf("Expected EQNR.OL while the US list loads; got: %+v", results)
+	}
+
+	close(provider.release)
+	if err := <-done; err != nil {
+		t.Errorf("Lookup should not give error; got: %s", err.Error())
+	}
+}
+
+func TestSymbolDirectoryKeepsStaleListOnFailure(t *testing.T) {
+	provider := testSymbolList()
+	directory := NewSymbolDirectory(provider, time.Hour)
+	now := time.Date(2025, 6, 18, 12, 0, 0, 0, time.UTC)
+	directory.now = func() time.Time { return now }
+
+	symbol, _ := ParseSymbol("AAPL")
+	directory.Lookup(symbol)
+	directory.Lookup(symbol)
+	if provider.calls != 1 {
+		t.Errorf("Expected the list to be loaded once within the TTL; got: %d loads", provider.calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	provider.failing = true
+	if _, err := directory.Lookup(symbol); err != nil {
+		t.Errorf("Lookup should use the expired list when loading fails; got: %s", err.Error())
+	}
+	if provider.calls != 2 {
+		t.Errorf("Expected the expired list to be reloaded; got: %d loads", provider.calls)
+	}
+}
+
+func TestEODHDProviderGetSymbols(t *testing.T) {
+	stub := NewEODHDStub(map[string][]models.IntradayData{
+		"AAPL":    testCandles(marketOpen, 1),
+		"EQNR.OL": testCandles(marketOpen, 1),
+	})
+	defer stub.Close()
+
+	oslo, _ := LookupExchange("OL")
+	symbols, err := NewEODHDProvider("token", stub.URL).GetSymbols(oslo)
+	if err != nil {
+		t.Fatalf("GetSymbols should not give error; got: %s", err.Error())
+	}
+	if len(symbols) != 1 || symbols[0].Symbol != "EQNR.OL" {
+		t.Errorf("Expected only EQNR.OL; got: %+v", symbols)
+	}
+}
@@ -0,0 +1,253 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+// Price models of the synthetic data provider
+const (
+	ModelGBM             = "gbm"              // Geometric Brownian motion, a random walk with drift
+	ModelMeanReverting   = "mean_reverting"   // Ornstein-Uhlenbeck process on the log price, pulled to the mean price
+	ModelRegimeSwitching = "regime_switching" // Geometric Brownian motion switching between regimes at random
+)
+
+// Regime is a state of the regime switching model with its own annual drift and volatility
+type Regime struct {
+	Drift      float64 `json:"drift"`
+	Volatility float64 `json:"volatility"`
+}
+
+// SyntheticConfig describes the candles generated by the synthetic data provider. Drift and
+// volatility are annual, a year being TradingDaysPerYear days of regular sessions.
+type SyntheticConfig struct {
+	Model string `json:"model"`
+	Seed  int64  `json:"seed"`
+	// Dates of the series, YYYY-MM-DD: it starts at the start price on the first session of Start,
+	// and requests without a to time end on End
+	Start      string  `json:"start"`
+	End        string  `json:"end"`
+	StartPrice float64 `json:"start_price"`
+	Drift      float64 `json:"drift"`
+	Volatility float64 `json:"volatility"`
+	// Mean reverting: the price the series is pulled to (the start price when zero) and how fast,
+	// the half-life of a deviation is ln(2)/ReversionSpeed years
+	MeanPrice      float64 `json:"mean_price"`
+	ReversionSpeed float64 `json:"reversion_speed"`
+	// Regime switching: the regimes and the chance of switching to another one on each candle
+	Regimes           []Regime `json:"regimes"`
+	SwitchProbability float64  `json:"switch_probability"`
+	// Average volume of a 1 minute candle
+	Volume float64 `json:"volume"`
+	// Data problems: the chance of a missing candle, and of a single candle spike of SpikeSize that reverts on the next candle
+	GapProbability   float64 `json:"gap_probability"`
+	SpikeProbability float64 `json:"spike_probability"`
+	SpikeSize        float64 `json:"spike_size"`
+}
+
+// DefaultSyntheticConfig is a clean random walk with the drift and volatility of a large stock
+func DefaultSyntheticConfig() SyntheticConfig {
+	return SyntheticConfig{
+		Model:          ModelGBM,
+		Seed:           1,
+		Start:          "2020-01-01",
+		End:            "2025-12-31",
+		StartPrice:     100,
+		Drift:          0.05,
+		Volatility:     0.2,
+		ReversionSpeed: 50,
+		Regimes: []Regime{
+			{Drift: 0.3, Volatility: 0.15},
+			{Drift: -0.4, Volatility: 0.45},
+		},
+		SwitchProbability: 1.0 / (390 * 20),
+		Volume:            10000,
+		SpikeSize:         0.2,
+	}
+}
+
+// LoadSyntheticConfig reads a synthetic config from a JSON file, missing fields keep their defaults
+func LoadSyntheticConfig(path string) (SyntheticConfig, error) {
+	config := DefaultSyntheticConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read synthetic config: %v", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse synthetic config: %v", err)
+	}
+	return config, config.Validate()
+}
+
+// Validate returns an error for unknown models and values out of range
+func (c SyntheticConfig) Validate() error {
+	switch c.Model {
+	case ModelGBM, ModelMeanReverting:
+	case ModelRegimeSwitching:
+		if len(c.Regimes) == 0 {
+			return fmt.Errorf("regime switching model needs at least one regime")
+		}
+	default:
+		return fmt.Errorf("unknown synthetic model %s, expected gbm, mean_reverting or regime_switching", c.Model)
+	}
+
+	start, err := time.Parse(calendarDayLayout, c.Start)
+	if err != nil {
+		return fmt.Errorf("invalid synthetic start date %s, expected YYYY-MM-DD", c.Start)
+	}
+	end, err := time.Parse(calendarDayLayout, c.End)
+	if err != nil {
+		return fmt.Errorf("invalid synthetic end date %s, expected YYYY-MM-DD", c.End)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("synthetic end date %s is before the start date %s", c.End, c.Start)
+	}
+
+	if c.StartPrice <= 0 || c.MeanPrice < 0 {
+		return fmt.Errorf("synthetic prices must be positive")
+	}
+	if c.Volatility < 0 || c.ReversionSpeed < 0 || c.Volume < 0 || c.SpikeSize < 0 {
+		return fmt.Errorf("synthetic volatility, reversion speed, volume and spike size can't be negative")
+	}
+	for _, probability := range []float64{c.SwitchProbability, c.GapProbability, c.SpikeProbability} {
+		if probability < 0 || probability > 1 {
+			return fmt.Errorf("synthetic probabilities must be from 0 to 1")
+		}
+	}
+	return nil
+}
+
+// SyntheticProvider generates candles within the sessions of the exchange of a symbol from a price
+// model. Each symbol gets one series from the start date of the config, and requests cut their range
+// out of it, so the same seed and symbol always give the same candle for the same minute.
+type SyntheticProvider struct {
+	config SyntheticConfig
+}
+
+func NewSyntheticProvider(config SyntheticConfig) *SyntheticProvider {
+	return &SyntheticProvider{config: config}
+}
+
+func (p *SyntheticProvider) GetIntradayData(request DataRequest) ([]models.IntradayData, error) {
+	defer utils.MeasureTime(time.Now(), "SyntheticProvider.GetIntradayData")
+
+	if err := p.config.Validate(); err != nil {
+		return nil, err
+	}
+	symbol, err := ParseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := symbol.Exchange.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a to time the range ends on the end date rather than now, so it doesn't change between calls
+	to := request.To
+	if to.IsZero() {
+		end, _ := time.ParseInLocation(calendarDayLayout, p.config.End, location)
+		to = end.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	from := request.From
+	if from.IsZero() {
+		from = to.Add(-intervalLookback[request.interval()])
+	}
+
+	intradayData, err := FilterIntradayData(p.generate(symbol, from, to), symbol.Exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter data %v", err)
+	}
+
+	return Resample(intradayData, request.interval(), symbol.Exchange)
+}
+
+// Generates the series of the symbol from the start date up to to, and keeps the 1 minute candles of
+// the sessions from from to to
+func (p *SyntheticProvider) generate(symbol Symbol, from, to time.Time) []models.IntradayData {
+	random := rand.New(rand.NewSource(p.config.Seed ^ symbolSeed(symbol.String())))
+	location, _ := symbol.Exchange.Location()
+	start, _ := time.ParseInLocation(calendarDayLayout, p.config.Start, location)
+
+	// Length of a candle in years of regular sessions
+	sessionMinutes := 0.0
+	for _, session := range symbol.Exchange.Sessions {
+		sessionMinutes += (session.Close - session.Open).Minutes()
+	}
+	dt := 1 / (TradingDaysPerYear * sessionMinutes)
+
+	meanPrice := p.config.MeanPrice
+	if meanPrice == 0 {
+		meanPrice = p.config.StartPrice
+	}
+
+	logPrice := math.Log(p.config.StartPrice)
+	regime := 0
+	data := []models.IntradayData{}
+	for day := start; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, session := range symbol.Exchange.SessionsOn(day) {
+			for offset := session.Open; offset < session.Close; offset += time.Minute {
+				timestamp := at(day, offset)
+				if timestamp.After(to) {
+					break
+				}
+
+				drift, volatility := p.config.Drift, p.config.Volatility
+				switch p.config.Model {
+				case ModelMeanReverting:
+					drift = p.config.ReversionSpeed*(math.Log(meanPrice)-logPrice) + volatility*volatility/2
+				case ModelRegimeSwitching:
+					if len(p.config.Regimes) > 1 && random.Float64() < p.config.SwitchProbability {
+						next := random.Intn(len(p.config.Regimes) - 1)
+						if next >= regime {
+							next++
+						}
+						regime = next
+					}
+					drift, volatility = p.config.Regimes[regime].Drift, p.config.Regimes[regime].Volatility
+				}
+
+				// The same random numbers are drawn for every candle, so gaps and spikes don't change the path
+				step := volatility * math.Sqrt(dt)
+				open := math.Exp(logPrice)
+				logPrice += (drift-volatility*volatility/2)*dt + step*random.NormFloat64()
+				close := math.Exp(logPrice)
+				high := math.Max(open, close) * math.Exp(math.Abs(random.NormFloat64())*step/2)
+				low := math.Min(open, close) * math.Exp(-math.Abs(random.NormFloat64())*step/2)
+				volume := p.config.Volume * math.Exp(random.NormFloat64()/2-0.125)
+				gap, spike, spikeUp := random.Float64(), random.Float64(), random.Float64() < 0.5
+
+				// Candles before the range are generated all the same, so the path doesn't depend on it
+				if timestamp.Before(from) || gap < p.config.GapProbability {
+					continue
+				}
+				if spike < p.config.SpikeProbability {
+					factor := 1 + p.config.SpikeSize
+					if !spikeUp {
+						factor = 1 / factor
+					}
+					close *= factor
+					high = math.Max(high, close)
+					low = math.Min(low, close)
+				}
+				data = append(data, newCandle(timestamp, open, high, low, close, volume))
+			}
+		}
+	}
+	return data
+}
+
+// Seed of the series of a symbol, so symbols with the same config differ
+func symbolSeed(symbol string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(symbol))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,251 @@
+package marketdata
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// The trading days of June 2025 on the US exchange
+var syntheticRequest = DataRequest{
+	Symbol: "AAPL",
+	From:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	To:     time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+}
+
+func TestSyntheticProviderIsReproducible(t *testing.T) {
+	provider := NewSyntheticProvider(DefaultSyntheticConfig())
+
+	first, err := provider.GetIntradayData(syntheticRequest)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	second, _ := provider.GetIntradayData(syntheticRequest)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same candles for the same seed")
+	}
+
+	// 20 trading days of 390 minutes, Juneteenth is a holiday
+	if len(first) != 20*390 {
+		t.Errorf("Expected %d candles; got: %d", 20*390, len(first))
+	}
+
+	other := syntheticRequest
+	other.Symbol = "MSFT"
+	third, _ := provider.GetIntradayData(other)
+	if reflect.DeepEqual(first, third) {
+		t.Errorf("Expected other candles for another symbol")
+	}
+}
+
+func TestSyntheticProviderRangesOverlap(t *testing.T) {
+	provider := NewSyntheticProvider(DefaultSyntheticConfig())
+
+	month, _ := provider.GetIntradayData(syntheticRequest)
+	week := syntheticRequest
+	week.From = time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)
+	week.To = time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC)
+	part, err := provider.GetIntradayData(week)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	// The week is cut out of the same series as the month
+	index := map[int64]int{}
+	for i, candle := range month {
+		index[candle.Timestamp] = i
+	}
+	if len(part) == 0 {
+		t.Fatalf("Expected candles in the week")
+	}
+	for _, candle := range part {
+		i, exists := index[candle.Timestamp]
+		if !exists || month[i] != candle {
+			t.Fatalf("Expected the same candle at %d in both ranges; got: %+v", candle.Timestamp, candle)
+		}
+	}
+
+	// Without a range the candles end on the end date of the config, whenever they're asked for
+	first, _ := provider.GetIntradayData(DataRequest{Symbol: "AAPL"})
+	second, _ := provider.GetIntradayData(DataRequest{Symbol: "AAPL"})
+	if len(first) == 0 || !reflect.DeepEqual(first, second) {
+		t.Fatalf("Expected the same candles without a range")
+	}
+	if last := time.Unix(first[len(first)-1].Timestamp, 0).UTC(); last.Format(calendarDayLayout) != "2025-12-31" {
+		t.Errorf("Expected the last candle on 2025-12-31; got: %s", last)
+	}
+}
+
+func TestSyntheticGBMVolatility(t *testing.T) {
+	config := DefaultSyntheticConfig()
+	config.Volatility = 0.3
+
+	request := syntheticRequest
+	request.From = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	request.To = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	data, err := NewSyntheticProvider(config).GetIntradayData(request)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	sum, sumSquares := 0.0, 0.0
+	for _, candle := range data {
+		r := math.Log(candle.Close / candle.Open)
+		sum += r
+		sumSquares += r * r
+	}
+	n := float64(len(data))
+	variance := sumSquares/n - (sum/n)*(sum/n)
+	annualized := math.Sqrt(variance * n)
+
+	// A year of data, so the variance of the returns adds up to the annual variance
+	if math.Abs(annualized-0.3) > 0.01 {
+		t.Errorf("Expected an annualized volatility of 0.3; got: %.4f", annualized)
+	}
+	for _, candle := range data {
+		if candle.High < math.Max(candle.Open, candle.Close) || candle.Low > math.Min(candle.Open, candle.Close) {
+			t.Fatalf("Expected valid candles; got: %+v", candle)
+		}
+	}
+}
+
+func TestSyntheticMeanReverting(t *testing.T) {
+	config := DefaultSyntheticConfig()
+	config.Model = ModelMeanReverting
+	config.Start = "2025-06-01"
+	config.StartPrice = 150
+	config.MeanPrice = 100
+
+	data, err := NewSyntheticProvider(config).GetIntradayData(syntheticRequest)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	// The half-life is a few days, so the last week is back around the mean
+	sum := 0.0
+	lastWeek := data[len(data)-5*390:]
+	for _, candle := range lastWeek {
+		sum += candle.Close
+	}
+	if average := sum / float64(len(lastWeek)); math.Abs(average-100) > 5 {
+		t.Errorf("Expected prices around 100 at the end; got an average of %.2f", average)
+	}
+}
+
+func TestSyntheticRegimeSwitching(t *testing.T) {
+	config := DefaultSyntheticConfig()
+	config.Model = ModelRegimeSwitching
+	config.Start = "2024-01-01"
+	request := syntheticRequest
+	request.From = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	request.To = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Without volatility every return is the drift of the regime, so the regime of each candle shows
+	config.Regimes = []Regime{{Drift: 0.5}, {Drift: -0.5}}
+	data, err := NewSyntheticProvider(config).GetIntradayData(request)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+	dt := 1 / float64(TradingDaysPerYear*390)
+	regimes, switches := [2]int{}, 0
+	for i, candle := range data {
+		r := math.Log(candle.Close/candle.Open) / dt
+		regime := 0
+		if r < 0 {
+			regime = 1
+		}
+		if math.Abs(r-config.Regimes[regime].Drift) > 1e-6 {
+			t.Fatalf("Expected the drift of a regime; got: %.4f", r)
+		}
+		regimes[regime]++
+		if i > 0 && (math.Log(data[i-1].Close/data[i-1].Open) < 0) != (r < 0) {
+			switches++
+		}
+	}
+	// A switch every 20 days on average
+	if regimes[0] == 0 || regimes[1] == 0 || switches < 5 || switches > 25 {
+		t.Errorf("Expected about 12 switches between both regimes; got: %d switches, %v candles", switches, regimes)
+	}
+
+	// Days in the calm regime have a lower volatility than days in the volatile one
+	config.Regimes = []Regime{{Volatility: 0.1}, {Volatility: 0.8}}
+	data, _ = NewSyntheticProvider(config).GetIntradayData(request)
+	calm, volatile := 0, 0
+	for day := 0; day+390 <= len(data); day += 390 {
+		sumSquares := 0.0
+		for _, candle := range data[day : day+390] {
+			r := math.Log(candle.Close / candle.Open)
+			sumSquares += r * r
+		}
+		annualized := math.Sqrt(sumSquares * float64(TradingDaysPerYear))
+		switch {
+		case annualized < 0.2:
+			calm++
+		case annualized > 0.6:
+			volatile++
+		}
+	}
+	if calm == 0 || volatile == 0 {
+		t.Errorf("Expected calm and volatile days; got: %d calm, %d volatile", calm, volatile)
+	}
+}
+
+func TestSyntheticGapsAndSpikes(t *testing.T) {
+	clean := DefaultSyntheticConfig()
+	clean.Model = ModelRegimeSwitching
+	dirty := clean
+	dirty.GapProbability = 0.01
+	dirty.SpikeProbability = 0.001
+
+	cleanData, _ := NewSyntheticProvider(clean).GetIntradayData(syntheticRequest)
+	dirtyData, err := NewSyntheticProvider(dirty).GetIntradayData(syntheticRequest)
+	if err != nil {
+		t.Fatalf("GetIntradayData should not give error; got: %s", err.Error())
+	}
+
+	missing := len(cleanData) - len(dirtyData)
+	if missing == 0 {
+		t.Fatalf("Expected missing candles")
+	}
+
+	us, _ := LookupExchange("US")
+	config := DefaultQualityConfig()
+	config.Gaps = RepairFill
+	_, report, err := CheckQuality(dirtyData, us, Interval1Minute, config)
+	if err != nil {
+		t.Fatalf("CheckQuality should not give error; got: %s", err.Error())
+	}
+	if report.Spikes == 0 {
+		t.Errorf("Expected the injected spikes to be found")
+	}
+	// Gaps at the start or end of the range and candles dropped as spikes aren't counted as missing
+	if report.MissingBars == 0 || report.MissingBars > missing+report.Spikes {
+		t.Errorf("Expected up to %d missing bars; got: %d", missing+report.Spikes, report.MissingBars)
+	}
+
+	_, report, _ = CheckQuality(cleanData, us, Interval1Minute, config)
+	if report.Spikes != 0 || report.MissingBars != 0 {
+		t.Errorf("Expected clean data without spikes or gaps; got: %+v", report)
+	}
+}
+
+func TestSyntheticConfigValidate(t *testing.T) {
+	config := DefaultSyntheticConfig()
+	config.Model = "random"
+	if config.Validate() == nil {
+		t.Errorf("Validate should give error for an unknown model but didn't get any")
+	}
+
+	config = DefaultSyntheticConfig()
+	config.End = "2019-12-31"
+	if config.Validate() == nil {
+		t.Errorf("Validate should give error for an end before the start but didn't get any")
+	}
+
+	config = DefaultSyntheticConfig()
+	config.GapProbability = 2
+	if config.Validate() == nil {
+		t.Errorf("Validate should give error for a probability above 1 but didn't get any")
+	}
+}
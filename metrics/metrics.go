@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+	"trend-hencher-api/models"
+	"trend-hencher-api/utils"
+)
+
+// Layout of the buy and sell dates stored on transactions
+const DateLayout = "2006-01-02 15:04:05"
+
+// Profit factor reported when there are winning trades but no losing ones
+const maxProfitFactor = 100
+
+const hoursPerYear = 365.25 * 24
+
+// Shorter periods aren't annualized, compounding a few days of returns to a year gives absurd values
+const minAnnualizedYears = 0.25
+
+// Metrics holds the standard performance metrics of the transactions of one trend. Returns are
+// fractions (0.05 is 5%) and the equity curve compounds each trade on the full amount.
+type Metrics struct {
+	Trades           int     `json:"trades"`
+	NetProfit        float64 `json:"net_profit"` // In currency, using the volume of each transaction
+	TotalReturn      float64 `json:"total_return"`
+	AnnualizedReturn float64 `json:"annualized_return"`
+	Sharpe           float64 `json:"sharpe"`
+	Sortino          float64 `json:"sortino"`
+	Calmar           float64 `json:"calmar"`
+	MaxDrawdown      float64 `json:"max_drawdown"`
+	MaxDrawdownHours float64 `json:"max_drawdown_hours"` // Longest time from a peak until it is recovered
+	ProfitFactor     float64 `json:"profit_factor"`
+	Expectancy       float64 `json:"expectancy"` // Expected return of a single trade
+	WinRate          float64 `json:"win_rate"`
+	AverageWin       float64 `json:"average_win"`
+	AverageLoss      float64 `json:"average_loss"` // Negative return
+	AverageReturn    float64 `json:"average_return"`
+	MedianReturn     float64 `json:"median_return"`
+	Exposure         float64 `json:"exposure"` // Fraction of the period spent in a position
+	AverageMAE       float64 `json:"average_mae"`
+	AverageMFE       float64 `json:"average_mfe"`
+	AverageBarsHeld  float64 `json:"average_bars_held"`
+}
+
+// ParseDate parses the buy and sell dates of transactions
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
+
+// TradeReturn is the return of a single transaction
+func TradeReturn(transaction models.Transaction) float64 {
+	if transaction.PriceBought == 0 {
+		return 0
+	}
+	return (transaction.PriceSold - transaction.PriceBought) / transaction.PriceBought
+}
+
+// CalendarYears is the length of the period from start to end in calendar years
+func CalendarYears(start, end time.Time) float64 {
+	return end.Sub(start).Hours() / hoursPerYear
+}
+
+// Calculate computes the metrics of the transactions made between start and end, the period
+// of the data the trend was run on. It is used to annualize returns and calculate exposure.
+func Calculate(transactions []models.Transaction, start, end time.Time) Metrics {
+	return CalculateOverYears(transactions, start, end, CalendarYears(start, end))
+}
+
+// CalculateOverYears is Calculate with the length of the period in years given, like the trading
+// days of the exchange divided by 252, so nights, weekends and holidays don't dilute the returns.
+func CalculateOverYears(transactions []models.Transaction, start, end time.Time, years float64) Metrics {
+	m := Metrics{Trades: len(transactions)}
+	if len(transactions) == 0 {
+		return m
+	}
+
+	returns := make([]float64, len(transactions))
+	var grossProfit, grossLoss, wins, losses float64
+	var winCount, lossCount int
+	var timeInMarket time.Duration
+	for i, transaction := range transactions {
+		returns[i] = TradeReturn(transaction)
+		m.NetProfit += (transaction.PriceSold - transaction.PriceBought) * float64(transaction.Volume)
+
+		switch {
+		case returns[i] > 0:
+			winCount++
+			wins += returns[i]
+			grossProfit += returns[i]
+		case returns[i] < 0:
+			lossCount++
+			losses += returns[i]
+			grossLoss -= returns[i]
+		}
+
+		m.AverageMAE += transaction.MaxAdverseExcursion / float64(len(transactions))
+		m.AverageMFE += transaction.MaxFavorableExcursion / float64(len(transactions))
+		m.AverageBarsHeld += float64(transaction.BarsHeld) / float64(len(transactions))
+
+		bought, errBought := ParseDate(transaction.DateBought)
+		sold, errSold := ParseDate(transaction.DateSold)
+		if errBought == nil && errSold == nil {
+			timeInMarket += sold.Sub(bought)
+		}
+	}
+
+	m.WinRate = float64(winCount) / float64(len(transactions))
+	if winCount > 0 {
+		m.AverageWin = wins / float64(winCount)
+	}
+	if lossCount > 0 {
+		m.AverageLoss = losses / float64(lossCount)
+	}
+	m.Expectancy = m.WinRate*m.AverageWin + (1-m.WinRate)*m.AverageLoss
+
+	switch {
+	case grossLoss > 0:
+		m.ProfitFactor = grossProfit / grossLoss
+	case grossProfit > 0:
+		m.ProfitFactor = maxProfitFactor
+	}
+
+	m.AverageReturn = utils.CalculateAverage(returns)
+	sorted := append([]float64{}, returns...)
+	sort.Float64s(sorted)
+	m.MedianReturn = utils.CalculateMedian(sorted)
+
+	m.TotalReturn, m.MaxDrawdown, m.MaxDrawdownHours = equityCurve(transactions, returns, end)
+
+	if years > 0 {
+		switch {
+		case years < minAnnualizedYears:
+			m.AnnualizedReturn = m.TotalReturn
+		case m.TotalReturn > -1:
+			m.AnnualizedReturn = math.Pow(1+m.TotalReturn, 1/years) - 1
+		default:
+			m.AnnualizedReturn = -1
+		}
+
+		// Per trade ratios are scaled by the number of trades made per year
+		tradesPerYear := float64(len(transactions)) / years
+		m.Sharpe = sharpe(returns, tradesPerYear)
+		m.Sortino = sortino(returns, tradesPerYear)
+		m.Exposure = math.Min(timeInMarket.Hours()/end.Sub(start).Hours(), 1)
+	}
+
+	if m.MaxDrawdown > 0 {
+		m.Calmar = m.AnnualizedReturn / m.MaxDrawdown
+	}
+
+	// Keep the metrics JSON encodable, an overflow stays the largest value rather than 0
+	m.AnnualizedReturn = finite(m.AnnualizedReturn)
+	m.Calmar = finite(m.Calmar)
+
+	return m
+}
+
+// Compounds the returns and finds the deepest and the longest drawdown of the equity
+func equityCurve(transactions []models.Transaction, returns []float64, end time.Time) (totalReturn, maxDrawdown, maxDrawdownHours float64) {
+	equity, peak := 1.0, 1.0
+	var peakTime time.Time
+	if bought, err := ParseDate(transactions[0].DateBought); err == nil {
+		peakTime = bought
+	}
+
+	inDrawdown := false
+	for i, r := range returns {
+		equity *= 1 + r
+		sold, err := ParseDate(transactions[i].DateSold)
+
+		if equity < peak {
+			maxDrawdown = math.Max(maxDrawdown, (peak-equity)/peak)
+			inDrawdown = true
+			continue
+		}
+
+		// New peak, which ends the drawdown if there was one
+		if inDrawdown && err == nil && !peakTime.IsZero() {
+			maxDrawdownHours = math.Max(maxDrawdownHours, sold.Sub(peakTime).Hours())
+		}
+		inDrawdown = false
+		peak = equity
+		peakTime = sold
+	}
+
+	// A drawdown that is not recovered lasts until the end of the period
+	if inDrawdown && !peakTime.IsZero() {
+		maxDrawdownHours = math.Max(maxDrawdownHours, end.Sub(peakTime).Hours())
+	}
+
+	return equity - 1, maxDrawdown, maxDrawdownHours
+}
+
+func sharpe(returns []float64, periodsPerYear float64) float64 {
+	mean := utils.CalculateAverage(returns)
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(returns)))
+	if deviation == 0 {
+		return 0
+	}
+	return mean / deviation * math.Sqrt(periodsPerYear)
+}
+
+func sortino(returns []float64, periodsPerYear float64) float64 {
+	mean := utils.CalculateAverage(returns)
+	downside := 0.0
+	for _, r := range returns {
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	deviation := math.Sqrt(downside / float64(len(returns)))
+	if deviation == 0 {
+		return 0
+	}
+	return mean / deviation * math.Sqrt(periodsPerYear)
+}
+
+// Replaces NaN by 0 and infinity by the largest float of the same sign
+func finite(value float64) float64 {
+	switch {
+	case math.IsNaN(value):
+		return 0
+	case math.IsInf(value, 1):
+		return math.MaxFloat64
+	case math.IsInf(value, -1):
+		return -math.MaxFloat64
+	}
+	return value
+}
@@ -0,0 +1,275 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+	"trend-hencher-api/models"
+)
+
+func transaction(bought, sold string, priceBought, priceSold float64) models.Transaction {
+	return models.Transaction{
+		DateBought:  bought,
+		DateSold:    sold,
+		PriceBought: priceBought,
+		PriceSold:   priceSold,
+		Volume:      int64(1000000 / priceBought),
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCalculate(t *testing.T) {
+	transactions := []models.Transaction{
+		transaction("2025-01-01 10:00:00", "2025-01-01 12:00:00", 100, 110),
+		transaction("2025-01-02 10:00:00", "2025-01-02 12:00:00", 100, 90),
+		transaction("2025-01-03 10:00:00", "2025-01-03 12:00:00", 100, 120),
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	m := Calculate(transactions, start, end)
+
+	if m.Trades != 3 {
+		t.Errorf("Expected 3 trades; got: %d", m.Trades)
+	}
+	if !almostEqual(m.TotalReturn, 1.1*0.9*1.2-1) {
+		t.Errorf("Expected total return %.4f; got: %.4f", 1.1*0.9*1.2-1, m.TotalReturn)
+	}
+	if !almostEqual(m.MaxDrawdown, 0.1) {
+		t.Errorf("Expected max drawdown 0.1; got: %.4f", m.MaxDrawdown)
+	}
+	// From the peak on day 1 at 12:00 until it is recovered on day 3 at 12:00
+	if m.MaxDrawdownHours != 48 {
+		t.Errorf("Expected max drawdown duration 48 hours; got: %.2f", m.MaxDrawdownHours)
+	}
+	if !almostEqual(m.ProfitFactor, 3) {
+		t.Errorf("Expected profit factor 3; got: %.4f", m.ProfitFactor)
+	}
+	if !almostEqual(m.WinRate, 2.0/3) {
+		t.Errorf("Expected win rate 0.667; got: %.4f", m.WinRate)
+	}
+	if !almostEqual(m.AverageWin, 0.15) || !almostEqual(m.AverageLoss, -0.1) {
+		t.Errorf("Expected average win 0.15 and loss -0.1; got: %.4f and %.4f", m.AverageWin, m.AverageLoss)
+	}
+	if !almostEqual(m.Expectancy, m.AverageReturn) {
+		t.Errorf("Expected expectancy to equal average return %.4f; got: %.4f", m.AverageReturn, m.Expectancy)
+	}
+	if !almostEqual(m.Exposure, 6.0/96) {
+		t.Errorf("Expected exposure %.4f; got: %.4f", 6.0/96, m.Exposure)
+	}
+	if m.Sharpe <= 0 || m.Sortino <= m.Sharpe {
+		t.Errorf("Expected positive sharpe below sortino; got: %.4f and %.4f", m.Sharpe, m.Sortino)
+	}
+}
+
+func TestCalculateAnnualizedReturn(t *testing.T) {
+	transactions := []models.Transaction{
+		transaction("2025-01-02 10:00:00", "2025-01-02 12:00:00", 100, 121),
+	}
+	start := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// A few days are too short to annualize, the total return is kept
+	m := CalculateOverYears(transactions, start, start.AddDate(0, 0, 3), 3.0/252)
+	if !almostEqual(m.AnnualizedReturn, 0.21) {
+		t.Errorf("Expected the total return 0.21 for a few days; got: %.4f", m.AnnualizedReturn)
+	}
+
+	// 21% over two years is 10% a year
+	m = CalculateOverYears(transactions, start, start.AddDate(2, 0, 0), 2)
+	if !almostEqual(m.AnnualizedReturn, 0.1) {
+		t.Errorf("Expected annualized return 0.1 over two years; got: %.4f", m.AnnualizedReturn)
+	}
+
+	if finite(math.Inf(1)) != math.MaxFloat64 || finite(math.Inf(-1)) != -math.MaxFloat64 || finite(math.NaN()) != 0 {
+		t.Error("Expected overflows to keep their sign and NaN to be 0")
+	}
+}
+
+func TestCalculateWithoutTransactions(t *testing.T) {
+	m := Calculate(nil, time.Now(), time.Now())
+	if m != (Metrics{}) {
+		t.Errorf("Expected empty metrics; got: %+v", m)
+	}
+
+	scorer, err := GetScorer("")
+	if err != nil {
+		t.Fatalf("GetScorer should not give error; got: %s", err.Error())
+	}
+	if score := scorer.Score(m); score != 0 {
+		t.Errorf("Expected score 0 without transactions; got: %.3f", score)
+	}
+}
+
+func TestDefaultScorerOccurrence(t *testing.T) {
+	scorer, _ := GetScorer(DefaultScorer)
+
+	// 50 trades used to give 0 occurrence because of integer division, equal average and median
+	// returns give the full variance score
+	score := scorer.Score(Metrics{Trades: 50})
+	if score != 0.225 {
+		t.Errorf("Expected score 0.225 for 50 trades; got: %.3f", score)
+	}
+}
+
+func TestGetScorerUnknown(t *testing.T) {
+	if _, err := GetScorer("unknown"); err == nil {
+		t.Error("GetScorer should give error but didn't get any")
+	}
+}
+
+func TestScoringProfileCaps(t *testing.T) {
+	profile := ScoringProfile{
+		Name:    "test",
+		Weights: map[string]float64{"sharpe": 0.5, "max_drawdown": -0.5},
+		Caps:    map[string]float64{"sharpe": 2, "max_drawdown": 0.2},
+	}
+
+	// Sharpe is capped at 1, drawdown counts as 0.5
+	score := profile.Score(Metrics{Sharpe: 5, MaxDrawdown: 0.1})
+	if score != 0.25 {
+		t.Errorf("Expected score 0.25; got: %.3f", score)
+	}
+}
+
+func TestLoadScoringProfiles(t *testing.T) {
+	profiles, err := LoadScoringProfiles("scoring_profiles.json")
+	if err != nil {
+		t.Fatalf("LoadScoringProfiles should not give error; got: %s", err.Error())
+	}
+
+	for _, profile := range profiles {
+		if _, err := GetScorer(profile.Name); err != nil {
+			t.Errorf("Expected profile %s to be registered; got: %s", profile.Name, err.Error())
+		}
+	}
+
+	invalid := ScoringProfile{Name: "invalid", Weights: map[string]float64{"unknown": 1}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("Validate should give error for unknown metric but didn't get any")
+	}
+}
+
+func TestTTest(t *testing.T) {
+	// Mean 0.02 and sample standard deviation 0.01 over 11 returns gives t = 6.633
+	returns := []float64{0.01, 0.03, 0.01, 0.03, 0.01, 0.03, 0.01, 0.03, 0.01, 0.03, 0.02}
+	tStatistic, pValue := TTest(returns)
+
+	if math.Abs(tStatistic-6.633) > 0.001 {
+		t.Errorf("Expected t statistic 6.633; got: %.4f", tStatistic)
+	}
+	if pValue <= 0 || pValue > 0.0001 {
+		t.Errorf("Expected p-value below 0.0001; got: %.6f", pValue)
+	}
+
+	// t = 2.228 with 10 degrees of freedom is the 97.5% quantile
+	if p := studentTUpperTail(2.228, 10); math.Abs(p-0.025) > 0.0005 {
+		t.Errorf("Expected upper tail 0.025; got: %.5f", p)
+	}
+	if p := studentTUpperTail(-2.228, 10); math.Abs(p-0.975) > 0.0005 {
+		t.Errorf("Expected upper tail 0.975; got: %.5f", p)
+	}
+}
+
+func TestBootstrapPValue(t *testing.T) {
+	positive := []float64{0.01, 0.03, 0.01, 0.03, 0.01, 0.03, 0.01, 0.03, 0.01, 0.03}
+	if p := BootstrapPValue(positive, 2000, 1); p > 0.01 {
+		t.Errorf("Expected small p-value for positive returns; got: %.4f", p)
+	}
+
+	mixed := []float64{0.02, -0.02, 0.01, -0.01, 0.03, -0.03}
+	if p := BootstrapPValue(mixed, 2000, 1); p < 0.3 {
+		t.Errorf("Expected large p-value for returns around zero; got: %.4f", p)
+	}
+}
+
+func TestBenjaminiHochberg(t *testing.T) {
+	adjusted := BenjaminiHochberg([]float64{0.01, 0.04, 0.03, 0.2})
+
+	expected := []float64{0.04, 0.04 * 4 / 3, 0.04 * 4 / 3, 0.2}
+	for i := range expected {
+		if !almostEqual(adjusted[i], expected[i]) {
+			t.Errorf("Expected adjusted p-value %.4f at %d; got: %.4f", expected[i], i, adjusted[i])
+		}
+	}
+
+	results := []Significance{{PValue: 0.01}, {PValue: 0.04}, {PValue: 0.03}, {PValue: 0.2}}
+	AdjustSignificance(results)
+	if !results[0].Significant || results[1].Significant || results[3].Significant {
+		t.Errorf("Expected only the first result to be significant; got: %+v", results)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	transactions := []models.Transaction{
+		transaction("2025-01-30 10:00:00", "2025-01-30 11:00:00", 100, 110),
+		transaction("2025-01-31 10:00:00", "2025-01-31 10:30:00", 100, 99),
+		transaction("2025-02-03 10:00:00", "2025-02-03 12:00:00", 100, 98),
+		transaction("2025-02-03 13:00:00", "2025-02-03 14:00:00", 100, 101),
+	}
+
+	report := BuildReport("trend", transactions, time.Time{}, time.Time{}, 0)
+
+	if len(report.MonthlyReturns) != 2 || report.MonthlyReturns[0].Period != "2025-01" || report.MonthlyReturns[1].Trades != 2 {
+		t.Errorf("Expected 2 monthly returns for January and February; got: %+v", report.MonthlyReturns)
+	}
+	if !almostEqual(report.MonthlyReturns[0].Return, 1.1*0.99-1) {
+		t.Errorf("Expected compounded January return %.4f; got: %.4f", 1.1*0.99-1, report.MonthlyReturns[0].Return)
+	}
+	if len(report.DailyReturns) != 3 {
+		t.Errorf("Expected 3 daily returns; got: %d", len(report.DailyReturns))
+	}
+	if report.Trades[1].HoldingMinutes != 30 {
+		t.Errorf("Expected holding time of 30 minutes; got: %.0f", report.Trades[1].HoldingMinutes)
+	}
+	if report.LongestLosingStreak != 2 || report.LongestWinStreak != 1 {
+		t.Errorf("Expected losing streak 2 and win streak 1; got: %d and %d", report.LongestLosingStreak, report.LongestWinStreak)
+	}
+
+	total := 0
+	for _, bucket := range report.ReturnDistribution {
+		total += bucket.Count
+	}
+	if total != len(transactions) {
+		t.Errorf("Expected %d trades in the histogram; got: %d", len(transactions), total)
+	}
+	if len(report.Exposure) != len(transactions) {
+		t.Errorf("Expected an exposure point per trade; got: %d", len(report.Exposure))
+	}
+}
+
+func TestReturnDistributionClampsOutliers(t *testing.T) {
+	transactions := []models.Transaction{
+		transaction("2025-01-30 10:00:00", "2025-01-30 11:00:00", 100, 101),
+		// A bad tick making a return of 99900%
+		transaction("2025-01-31 10:00:00", "2025-01-31 10:30:00", 100, 100000),
+		transaction("2025-02-03 10:00:00", "2025-02-03 12:00:00", 100, 1),
+	}
+
+	buckets := returnDistribution(transactions)
+	if len(buckets) != 2*int(histogramMaxReturn/histogramBucketWidth) {
+		t.Fatalf("Expected the buckets to stop at -%.0f%% and %.0f%%; got: %d buckets", histogramMaxReturn*100, histogramMaxReturn*100, len(buckets))
+	}
+	first, last := buckets[0], buckets[len(buckets)-1]
+	if first.Count != 1 || !almostEqual(first.From, -0.99) || last.Count != 1 || !almostEqual(last.To, 999) {
+		t.Errorf("Expected the outliers in the end buckets widened to them; got: %+v and %+v", first, last)
+	}
+}
+
+func TestLoadDefaultScoringProfiles(t *testing.T) {
+	profiles, err := LoadDefaultScoringProfiles()
+	if err != nil {
+		t.Fatalf("LoadDefaultScoringProfiles should not give error; got: %s", err.Error())
+	}
+	if len(profiles) == 0 {
+		t.Fatal("Expected the embedded scoring profiles")
+	}
+
+	for _, profile := range profiles {
+		if _, err := GetScorer(profile.Name); err != nil {
+			t.Errorf("Expected profile %s to be registered; got: %s", profile.Name, err.Error())
+		}
+	}
+}
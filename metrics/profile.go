@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// The profiles shipped with the binary, so they don't depend on the working directory
+//
+//go:embed scoring_profiles.json
+var defaultScoringProfiles string
+
+// ScoringProfile is a scorer defined in scoring_profiles.json. The score is the sum of the weighted
+// metrics, where a metric with a cap is divided by it and limited to between -1 and 1.
+type ScoringProfile struct {
+	Name    string             `json:"name"`
+	Weights map[string]float64 `json:"weights"`
+	Caps    map[string]float64 `json:"caps"`
+}
+
+// Metrics that can be weighted in a profile, by their JSON name
+var metricValues = map[string]func(m Metrics) float64{
+	"trades":             func(m Metrics) float64 { return float64(m.Trades) },
+	"net_profit":         func(m Metrics) float64 { return m.NetProfit },
+	"total_return":       func(m Metrics) float64 { return m.TotalReturn },
+	"annualized_return":  func(m Metrics) float64 { return m.AnnualizedReturn },
+	"sharpe":             func(m Metrics) float64 { return m.Sharpe },
+	"sortino":            func(m Metrics) float64 { return m.Sortino },
+	"calmar":             func(m Metrics) float64 { return m.Calmar },
+	"max_drawdown":       func(m Metrics) float64 { return m.MaxDrawdown },
+	"max_drawdown_hours": func(m Metrics) float64 { return m.MaxDrawdownHours },
+	"profit_factor":      func(m Metrics) float64 { return m.ProfitFactor },
+	"expectancy":         func(m Metrics) float64 { return m.Expectancy },
+	"win_rate":           func(m Metrics) float64 { return m.WinRate },
+	"average_win":        func(m Metrics) float64 { return m.AverageWin },
+	"average_loss":       func(m Metrics) float64 { return m.AverageLoss },
+	"average_return":     func(m Metrics) float64 { return m.AverageReturn },
+	"median_return":      func(m Metrics) float64 { return m.MedianReturn },
+	"exposure":           func(m Metrics) float64 { return m.Exposure },
+	"average_mae":        func(m Metrics) float64 { return m.AverageMAE },
+	"average_mfe":        func(m Metrics) float64 { return m.AverageMFE },
+	"average_bars_held":  func(m Metrics) float64 { return m.AverageBarsHeld },
+	"variance_score":     varianceScore,
+}
+
+// Makes a profile usable as a Scorer
+type profileScorer struct {
+	profile ScoringProfile
+}
+
+func (s profileScorer) Name() string {
+	return s.profile.Name
+}
+
+func (s profileScorer) Score(m Metrics) float64 {
+	return s.profile.Score(m)
+}
+
+func (p ScoringProfile) Score(m Metrics) float64 {
+	score := 0.0
+	for metric, weight := range p.Weights {
+		value := metricValues[metric](m)
+		if limit, capped := p.Caps[metric]; capped && limit > 0 {
+			value = math.Max(-1, math.Min(1, value/limit))
+		}
+		score += weight * value
+	}
+	return round(score)
+}
+
+// Validate checks that the profile only refers to known metrics
+func (p ScoringProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("scoring profile is missing a name")
+	}
+	if len(p.Weights) == 0 {
+		return fmt.Errorf("scoring profile %s has no weights", p.Name)
+	}
+	for metric := range p.Weights {
+		if _, exists := metricValues[metric]; !exists {
+			return fmt.Errorf("scoring profile %s: unknown metric %q", p.Name, metric)
+		}
+	}
+	for metric := range p.Caps {
+		if _, exists := p.Weights[metric]; !exists {
+			return fmt.Errorf("scoring profile %s: cap for unweighted metric %q", p.Name, metric)
+		}
+	}
+	return nil
+}
+
+// LoadScoringProfiles reads the profiles in filepath and registers them as scorers. A profile
+// named like a built-in scorer replaces it, so the default weights can be changed as well.
+func LoadScoringProfiles(filepath string) ([]ScoringProfile, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return registerScoringProfiles(file)
+}
+
+// LoadDefaultScoringProfiles registers the profiles of the scoring_profiles.json embedded in the binary
+func LoadDefaultScoringProfiles() ([]ScoringProfile, error) {
+	return registerScoringProfiles(strings.NewReader(defaultScoringProfiles))
+}
+
+// Decodes and validates the profiles before registering any of them
+func registerScoringProfiles(reader io.Reader) ([]ScoringProfile, error) {
+	var profiles []ScoringProfile
+	if err := json.NewDecoder(reader).Decode(&profiles); err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		if err := profile.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, profile := range profiles {
+		RegisterScorer(profileScorer{profile: profile})
+	}
+
+	return profiles, nil
+}
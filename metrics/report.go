@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"math"
+	"time"
+	"trend-hencher-api/models"
+)
+
+// Width of the return buckets in the win/loss histogram, 0.005 is half a percent. Returns beyond
+// histogramMaxReturn either way go into the end buckets, so an outlier can't add thousands of buckets.
+const (
+	histogramBucketWidth = 0.005
+	histogramMaxReturn   = 0.5
+)
+
+// Report is the full performance breakdown of a trend, built from its transactions
+type Report struct {
+	TrendID             string            `json:"trend_id"`
+	Start               time.Time         `json:"start"`
+	End                 time.Time         `json:"end"`
+	Metrics             Metrics           `json:"metrics"`
+	MonthlyReturns      []PeriodReturn    `json:"monthly_returns"`
+	DailyReturns        []PeriodReturn    `json:"daily_returns"`
+	Trades              []TradeReport     `json:"trades"`
+	ReturnDistribution  []HistogramBucket `json:"return_distribution"`
+	LongestWinStreak    int               `json:"longest_win_streak"`
+	LongestLosingStreak int               `json:"longest_losing_streak"`
+	Exposure            []ExposurePoint   `json:"exposure"`
+}
+
+// PeriodReturn is the compounded return of the trades sold within a month or day
+type PeriodReturn struct {
+	Period string  `json:"period"`
+	Return float64 `json:"return"`
+	Trades int     `json:"trades"`
+}
+
+// TradeReport is a transaction with its return, holding time and the equity after it was sold
+type TradeReport struct {
+	models.Transaction
+	Return         float64 `json:"return"`
+	HoldingMinutes float64 `json:"holding_minutes"`
+	Equity         float64 `json:"equity"`
+}
+
+// HistogramBucket counts the trades with a return from From up to To
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// ExposurePoint is the fraction of time spent in a position from the start until Time
+type ExposurePoint struct {
+	Time     time.Time `json:"time"`
+	Exposure float64   `json:"exposure"`
+}
+
+// BuildReport creates the report of the transactions of a trend, which are expected in the order
+// they were made. Start and end are the period of the data, when zero the period of the trades is used.
+// Years is the length of the period used to annualize, the calendar years from start to end when zero.
+func BuildReport(trendID string, transactions []models.Transaction, start, end time.Time, years float64) Report {
+	if len(transactions) > 0 {
+		if start.IsZero() {
+			start, _ = ParseDate(transactions[0].DateBought)
+		}
+		if end.IsZero() {
+			end, _ = ParseDate(transactions[len(transactions)-1].DateSold)
+		}
+	}
+	if years <= 0 {
+		years = CalendarYears(start, end)
+	}
+
+	report := Report{
+		TrendID:            trendID,
+		Start:              start,
+		End:                end,
+		Metrics:            CalculateOverYears(transactions, start, end, years),
+		MonthlyReturns:     periodReturns(transactions, "2006-01"),
+		DailyReturns:       periodReturns(transactions, "2006-01-02"),
+		Trades:             []TradeReport{},
+		ReturnDistribution: returnDistribution(transactions),
+		Exposure:           []ExposurePoint{},
+	}
+
+	equity := 1.0
+	winStreak, losingStreak := 0, 0
+	var timeInMarket time.Duration
+	for _, transaction := range transactions {
+		r := TradeReturn(transaction)
+		equity *= 1 + r
+
+		trade := TradeReport{Transaction: transaction, Return: r, Equity: equity}
+		bought, errBought := ParseDate(transaction.DateBought)
+		sold, errSold := ParseDate(transaction.DateSold)
+		if errBought == nil && errSold == nil {
+			trade.HoldingMinutes = sold.Sub(bought).Minutes()
+			timeInMarket += sold.Sub(bought)
+
+			if elapsed := sold.Sub(start); elapsed > 0 {
+				report.Exposure = append(report.Exposure, ExposurePoint{
+					Time:     sold,
+					Exposure: math.Min(timeInMarket.Hours()/elapsed.Hours(), 1),
+				})
+			}
+		}
+		report.Trades = append(report.Trades, trade)
+
+		switch {
+		case r > 0:
+			winStreak++
+			losingStreak = 0
+		case r < 0:
+			losingStreak++
+			winStreak = 0
+		default:
+			winStreak, losingStreak = 0, 0
+		}
+		report.LongestWinStreak = max(report.LongestWinStreak, winStreak)
+		report.LongestLosingStreak = max(report.LongestLosingStreak, losingStreak)
+	}
+
+	return report
+}
+
+// Groups the trades by the sell date formatted with layout and compounds their returns
+func periodReturns(transactions []models.Transaction, layout string) []PeriodReturn {
+	periods := []PeriodReturn{}
+	for _, transaction := range transactions {
+		sold, err := ParseDate(transaction.DateSold)
+		if err != nil {
+			continue
+		}
+
+		period := sold.Format(layout)
+		if len(periods) == 0 || periods[len(periods)-1].Period != period {
+			periods = append(periods, PeriodReturn{Period: period})
+		}
+
+		current := &periods[len(periods)-1]
+		current.Return = (1+current.Return)*(1+TradeReturn(transaction)) - 1
+		current.Trades++
+	}
+	return periods
+}
+
+// Histogram of the trade returns in buckets of histogramBucketWidth, the end buckets are widened to
+// the lowest and highest return when those are clamped to histogramMaxReturn
+func returnDistribution(transactions []models.Transaction) []HistogramBucket {
+	buckets := []HistogramBucket{}
+	if len(transactions) == 0 {
+		return buckets
+	}
+
+	limit := int(math.Round(histogramMaxReturn / histogramBucketWidth))
+	indexOf := func(r float64) int {
+		return min(max(int(math.Floor(r/histogramBucketWidth)), -limit), limit-1)
+	}
+
+	lowest, highest := math.MaxInt, math.MinInt
+	lowestReturn, highestReturn := math.Inf(1), math.Inf(-1)
+	for _, transaction := range transactions {
+		r := TradeReturn(transaction)
+		lowest = min(lowest, indexOf(r))
+		highest = max(highest, indexOf(r))
+		lowestReturn = min(lowestReturn, r)
+		highestReturn = max(highestReturn, r)
+	}
+
+	for index := lowest; index <= highest; index++ {
+		buckets = append(buckets, HistogramBucket{
+			From: float64(index) * histogramBucketWidth,
+			To:   float64(index+1) * histogramBucketWidth,
+		})
+	}
+	for _, transaction := range transactions {
+		buckets[indexOf(TradeReturn(transaction))-lowest].Count++
+	}
+
+	buckets[0].From = math.Min(buckets[0].From, lowestReturn)
+	buckets[len(buckets)-1].To = math.Max(buckets[len(buckets)-1].To, highestReturn)
+	return buckets
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultScorer is used when neither the request nor the scenario chooses a scoring model
+const DefaultScorer = "default"
+
+// Scorer turns the metrics of a trend into a single score used to compare trends
+type Scorer interface {
+	Name() string
+	Score(m Metrics) float64
+}
+
+var scorers = map[string]Scorer{}
+
+// RegisterScorer makes a scorer selectable by its name
+func RegisterScorer(scorer Scorer) {
+	scorers[scorer.Name()] = scorer
+}
+
+// GetScorer returns the scorer registered under name, the default scorer when name is empty
+func GetScorer(name string) (Scorer, error) {
+	if name == "" {
+		name = DefaultScorer
+	}
+	scorer, exists := scorers[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown scorer %q", name)
+	}
+	return scorer, nil
+}
+
+// ScorerNames lists the registered scorers
+func ScorerNames() []string {
+	names := []string{}
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterScorer(defaultScorer{})
+	RegisterScorer(metricScorer{name: "sharpe", metric: func(m Metrics) float64 { return m.Sharpe }})
+	RegisterScorer(metricScorer{name: "sortino", metric: func(m Metrics) float64 { return m.Sortino }})
+	RegisterScorer(metricScorer{name: "calmar", metric: func(m Metrics) float64 { return m.Calmar }})
+	RegisterScorer(metricScorer{name: "profit_factor", metric: func(m Metrics) float64 { return m.ProfitFactor }})
+	RegisterScorer(metricScorer{name: "expectancy", metric: func(m Metrics) float64 { return m.Expectancy }})
+}
+
+// Fake normalizations are being done - meaning any trend can have a score above 1
+// but most won't. When they go above 1 they are most likely very good trends!
+type defaultScorer struct{}
+
+func (defaultScorer) Name() string {
+	return DefaultScorer
+}
+
+func (defaultScorer) Score(m Metrics) float64 {
+	// Occurrence (assuming a max of 100)
+	normalizedOccurrence := float64(m.Trades) / 100
+	occurrenceWeight := 0.15
+
+	// Profitability (Assuming a max of 100%, and total invested: 1.000.000~ per trade)
+	normalizedProfitability := m.NetProfit / 1000000
+	profitabilityWeight := 0.45
+
+	// Consistency
+	normalizedConsistency := m.WinRate
+	consistencyWeight := 0.25
+
+	// Variance
+	normalizedVariance := varianceScore(m)
+	varianceWeight := 0.15
+
+	trendScore := occurrenceWeight*normalizedOccurrence + profitabilityWeight*normalizedProfitability + consistencyWeight*normalizedConsistency + varianceWeight*normalizedVariance
+	return round(trendScore)
+}
+
+// Difference between the average and median percentage profit, 1 when they are equal and 0 when
+// they are 1 percentage point or more apart
+func varianceScore(m Metrics) float64 {
+	if m.Trades == 0 {
+		return 0
+	}
+
+	variance := math.Abs(m.AverageReturn-m.MedianReturn) * 100
+
+	maxVariance := 1.0
+	score := 1 - (variance / maxVariance)
+	if score < 0 {
+		score = 0 // Ensure the score doesn't go below 0
+	}
+	return score
+}
+
+// Scores a trend by a single metric
+type metricScorer struct {
+	name   string
+	metric func(m Metrics) float64
+}
+
+func (s metricScorer) Name() string {
+	return s.name
+}
+
+func (s metricScorer) Score(m Metrics) float64 {
+	return round(s.metric(m))
+}
+
+func round(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"trend-hencher-api/models"
+)
+
+// Significance level used for the significant flag after the multiple-testing correction
+const SignificanceLevel = 0.05
+
+// Number of resamples for the bootstrap p-value
+const bootstrapIterations = 2000
+
+// Significance holds the test results of whether the trade returns of a trend are above zero.
+// PValue is the larger of the t-test and bootstrap p-values, AdjustedPValue is PValue after the
+// Benjamini-Hochberg correction across all trends tested in the same run.
+type Significance struct {
+	TStatistic      float64 `json:"t_statistic"`
+	TTestPValue     float64 `json:"t_test_p_value"`
+	BootstrapPValue float64 `json:"bootstrap_p_value"`
+	PValue          float64 `json:"p_value"`
+	AdjustedPValue  float64 `json:"adjusted_p_value"`
+	Significant     bool    `json:"significant"`
+}
+
+// TestSignificance tests the trade returns of the transactions against zero. The seed makes
+// the bootstrap repeatable. Fewer than 2 transactions can't be tested and give a p-value of 1.
+func TestSignificance(transactions []models.Transaction, seed int64) Significance {
+	s := Significance{TTestPValue: 1, BootstrapPValue: 1, PValue: 1, AdjustedPValue: 1}
+	if len(transactions) < 2 {
+		return s
+	}
+
+	returns := make([]float64, len(transactions))
+	for i, transaction := range transactions {
+		returns[i] = TradeReturn(transaction)
+	}
+
+	s.TStatistic, s.TTestPValue = TTest(returns)
+	s.BootstrapPValue = BootstrapPValue(returns, bootstrapIterations, seed)
+	s.PValue = math.Max(s.TTestPValue, s.BootstrapPValue)
+	s.AdjustedPValue = s.PValue
+	return s
+}
+
+// TTest is a one-sample t-test of the mean of the returns being above zero, returning the
+// t statistic and the one-sided p-value
+func TTest(returns []float64) (float64, float64) {
+	n := float64(len(returns))
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= n
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= n - 1
+
+	if variance == 0 {
+		if mean > 0 {
+			return math.MaxFloat64, 0
+		}
+		return 0, 1
+	}
+
+	t := mean / math.Sqrt(variance/n)
+	return t, studentTUpperTail(t, n-1)
+}
+
+// BootstrapPValue resamples the returns shifted to a mean of zero, giving the fraction of
+// resampled means that are at least the observed mean
+func BootstrapPValue(returns []float64, iterations int, seed int64) float64 {
+	n := len(returns)
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(n)
+
+	random := rand.New(rand.NewSource(seed))
+	atLeastObserved := 0
+	for i := 0; i < iterations; i++ {
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			sum += returns[random.Intn(n)] - mean
+		}
+		if sum/float64(n) >= mean {
+			atLeastObserved++
+		}
+	}
+
+	// Counting the observed sample itself keeps the p-value above zero
+	return float64(atLeastObserved+1) / float64(iterations+1)
+}
+
+// BenjaminiHochberg adjusts p-values for the false discovery rate of testing them together
+func BenjaminiHochberg(pValues []float64) []float64 {
+	n := len(pValues)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return pValues[order[a]] < pValues[order[b]] })
+
+	adjusted := make([]float64, n)
+	minimum := 1.0
+	for rank := n; rank >= 1; rank-- {
+		i := order[rank-1]
+		minimum = math.Min(minimum, pValues[i]*float64(n)/float64(rank))
+		adjusted[i] = minimum
+	}
+	return adjusted
+}
+
+// AdjustSignificance applies the Benjamini-Hochberg correction across the trends of one run
+// and sets the significant flag
+func AdjustSignificance(results []Significance) {
+	pValues := make([]float64, len(results))
+	for i, result := range results {
+		pValues[i] = result.PValue
+	}
+
+	for i, adjusted := range BenjaminiHochberg(pValues) {
+		results[i].AdjustedPValue = adjusted
+		results[i].Significant = adjusted <= SignificanceLevel
+	}
+}
+
+// Probability of a Student's t distribution with df degrees of freedom being above t
+func studentTUpperTail(t, df float64) float64 {
+	tail := 0.5 * regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+	if t < 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// Regularized incomplete beta function I_x(a, b), evaluated with a continued fraction
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgammaAB, _ := math.Lgamma(a + b)
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges quickly on this side, use symmetry otherwise
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// Modified Lentz's method for the continued fraction of the incomplete beta function
+func betaContinuedFraction(x, a, b float64) float64 {
+	const maxIterations = 200
+	const epsilon = 1e-14
+	const tiny = 1e-300
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	result := d
+
+	for m := 1; m <= maxIterations; m++ {
+		mf := float64(m)
+
+		// Even step
+		numerator := mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		result *= d * c
+
+		// Odd step
+		numerator = -(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		result *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return result
+}
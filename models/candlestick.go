@@ -0,0 +1,246 @@
+package models
+
+import "math"
+
+// Number of previous candles used to decide whether a body or shadow is long or short
+const candleAveragePeriod = 10
+
+// Candlestick patterns follow the TA-Lib CDL convention: 100 on a bullish detection, -100 on
+// a bearish detection and 0 otherwise. Patterns without a direction (like the doji) report 100.
+type candlestickPattern func(c candles, i int) float64
+
+// CandlestickPatterns holds every pattern that can be used as an indicator name in a condition
+var CandlestickPatterns = map[string]candlestickPattern{
+	"CDLDOJI":           cdlDoji,
+	"CDLMARUBOZU":       cdlMarubozu,
+	"CDLHAMMER":         cdlHammer,
+	"CDLHANGINGMAN":     cdlHangingMan,
+	"CDLINVERTEDHAMMER": cdlInvertedHammer,
+	"CDLSHOOTINGSTAR":   cdlShootingStar,
+	"CDLENGULFING":      cdlEngulfing,
+	"CDLHARAMI":         cdlHarami,
+	"CDLPIERCING":       cdlPiercing,
+	"CDLDARKCLOUDCOVER": cdlDarkCloudCover,
+	"CDLMORNINGSTAR":    cdlMorningStar,
+	"CDLEVENINGSTAR":    cdlEveningStar,
+	"CDL3WHITESOLDIERS": cdl3WhiteSoldiers,
+	"CDL3BLACKCROWS":    cdl3BlackCrows,
+}
+
+type candles struct {
+	open, high, low, close []float64
+}
+
+// CandlestickPattern evaluates the named pattern on every candle, returning nil for unknown patterns
+func CandlestickPattern(name string, open, high, low, close []float64) []float64 {
+	pattern, exists := CandlestickPatterns[name]
+	if !exists {
+		return nil
+	}
+
+	c := candles{open: open, high: high, low: low, close: close}
+	out := make([]float64, len(close))
+	for i := candleAveragePeriod; i < len(close); i++ {
+		out[i] = pattern(c, i)
+	}
+	return out
+}
+
+func (c candles) body(i int) float64 {
+	return math.Abs(c.close[i] - c.open[i])
+}
+
+func (c candles) rangeOf(i int) float64 {
+	return c.high[i] - c.low[i]
+}
+
+func (c candles) upperShadow(i int) float64 {
+	return c.high[i] - math.Max(c.open[i], c.close[i])
+}
+
+func (c candles) lowerShadow(i int) float64 {
+	return math.Min(c.open[i], c.close[i]) - c.low[i]
+}
+
+func (c candles) bullish(i int) bool {
+	return c.close[i] > c.open[i]
+}
+
+func (c candles) bearish(i int) bool {
+	return c.close[i] < c.open[i]
+}
+
+// Average body of the candles before i
+func (c candles) averageBody(i int) float64 {
+	sum := 0.0
+	for j := i - candleAveragePeriod; j < i; j++ {
+		sum += c.body(j)
+	}
+	return sum / candleAveragePeriod
+}
+
+func (c candles) longBody(i int) bool {
+	return c.body(i) > c.averageBody(i)
+}
+
+func (c candles) shortBody(i int) bool {
+	return c.body(i) < c.averageBody(i)/2
+}
+
+// Trend leading into candle i, measured over the candles before it
+func (c candles) downtrend(i int) bool {
+	return c.close[i-1] < c.close[i-candleAveragePeriod/2]
+}
+
+func (c candles) uptrend(i int) bool {
+	return c.close[i-1] > c.close[i-candleAveragePeriod/2]
+}
+
+func cdlDoji(c candles, i int) float64 {
+	if c.rangeOf(i) > 0 && c.body(i) <= 0.1*c.rangeOf(i) {
+		return 100
+	}
+	return 0
+}
+
+func cdlMarubozu(c candles, i int) float64 {
+	if !c.longBody(i) || c.upperShadow(i) > 0.05*c.rangeOf(i) || c.lowerShadow(i) > 0.05*c.rangeOf(i) {
+		return 0
+	}
+	if c.bullish(i) {
+		return 100
+	}
+	return -100
+}
+
+// Small body at the top of the candle with a long lower shadow
+func hammerShape(c candles, i int) bool {
+	body := c.body(i)
+	return c.rangeOf(i) > 0 && body < c.averageBody(i) &&
+		c.lowerShadow(i) >= 2*body && c.upperShadow(i) <= 0.1*c.rangeOf(i)
+}
+
+// Small body at the bottom of the candle with a long upper shadow
+func invertedHammerShape(c candles, i int) bool {
+	body := c.body(i)
+	return c.rangeOf(i) > 0 && body < c.averageBody(i) &&
+		c.upperShadow(i) >= 2*body && c.lowerShadow(i) <= 0.1*c.rangeOf(i)
+}
+
+func cdlHammer(c candles, i int) float64 {
+	if hammerShape(c, i) && c.downtrend(i) {
+		return 100
+	}
+	return 0
+}
+
+func cdlHangingMan(c candles, i int) float64 {
+	if hammerShape(c, i) && c.uptrend(i) {
+		return -100
+	}
+	return 0
+}
+
+func cdlInvertedHammer(c candles, i int) float64 {
+	if invertedHammerShape(c, i) && c.downtrend(i) {
+		return 100
+	}
+	return 0
+}
+
+func cdlShootingStar(c candles, i int) float64 {
+	if invertedHammerShape(c, i) && c.uptrend(i) {
+		return -100
+	}
+	return 0
+}
+
+func cdlEngulfing(c candles, i int) float64 {
+	switch {
+	case c.bearish(i-1) && c.bullish(i) && c.open[i] <= c.close[i-1] && c.close[i] >= c.open[i-1]:
+		return 100
+	case c.bullish(i-1) && c.bearish(i) && c.open[i] >= c.close[i-1] && c.close[i] <= c.open[i-1]:
+		return -100
+	}
+	return 0
+}
+
+func cdlHarami(c candles, i int) float64 {
+	if !c.longBody(i-1) || !c.shortBody(i) {
+		return 0
+	}
+
+	top := math.Max(c.open[i], c.close[i])
+	bottom := math.Min(c.open[i], c.close[i])
+	switch {
+	case c.bearish(i-1) && top < c.open[i-1] && bottom > c.close[i-1]:
+		return 100
+	case c.bullish(i-1) && top < c.close[i-1] && bottom > c.open[i-1]:
+		return -100
+	}
+	return 0
+}
+
+func cdlPiercing(c candles, i int) float64 {
+	midpoint := (c.open[i-1] + c.close[i-1]) / 2
+	if c.bearish(i-1) && c.longBody(i-1) && c.bullish(i) &&
+		c.open[i] < c.low[i-1] && c.close[i] > midpoint && c.close[i] < c.open[i-1] {
+		return 100
+	}
+	return 0
+}
+
+func cdlDarkCloudCover(c candles, i int) float64 {
+	midpoint := (c.open[i-1] + c.close[i-1]) / 2
+	if c.bullish(i-1) && c.longBody(i-1) && c.bearish(i) &&
+		c.open[i] > c.high[i-1] && c.close[i] < midpoint && c.close[i] > c.open[i-1] {
+		return -100
+	}
+	return 0
+}
+
+func cdlMorningStar(c candles, i int) float64 {
+	midpoint := (c.open[i-2] + c.close[i-2]) / 2
+	if c.bearish(i-2) && c.longBody(i-2) && c.shortBody(i-1) &&
+		math.Max(c.open[i-1], c.close[i-1]) < c.close[i-2] &&
+		c.bullish(i) && c.close[i] > midpoint {
+		return 100
+	}
+	return 0
+}
+
+func cdlEveningStar(c candles, i int) float64 {
+	midpoint := (c.open[i-2] + c.close[i-2]) / 2
+	if c.bullish(i-2) && c.longBody(i-2) && c.shortBody(i-1) &&
+		math.Min(c.open[i-1], c.close[i-1]) > c.close[i-2] &&
+		c.bearish(i) && c.close[i] < midpoint {
+		return -100
+	}
+	return 0
+}
+
+func cdl3WhiteSoldiers(c candles, i int) float64 {
+	for j := i - 2; j <= i; j++ {
+		if !c.bullish(j) || c.upperShadow(j) > c.body(j)/2 {
+			return 0
+		}
+		// Each candle opens within the previous body and closes higher
+		if j > i-2 && (c.open[j] < c.open[j-1] || c.open[j] > c.close[j-1] || c.close[j] <= c.close[j-1]) {
+			return 0
+		}
+	}
+	return 100
+}
+
+func cdl3BlackCrows(c candles, i int) float64 {
+	for j := i - 2; j <= i; j++ {
+		if !c.bearish(j) || c.lowerShadow(j) > c.body(j)/2 {
+			return 0
+		}
+		// Each candle opens within the previous body and closes lower
+		if j > i-2 && (c.open[j] > c.open[j-1] || c.open[j] < c.close[j-1] || c.close[j] >= c.close[j-1]) {
+			return 0
+		}
+	}
+	return -100
+}
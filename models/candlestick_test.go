@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+// Builds candles from open/close pairs with shadows of 0.1 on both sides
+func testCandles(bodies [][2]float64) (open, high, low, close []float64) {
+	for _, b := range bodies {
+		open = append(open, b[0])
+		close = append(close, b[1])
+		high = append(high, max(b[0], b[1])+0.1)
+		low = append(low, min(b[0], b[1])-0.1)
+	}
+	return open, high, low, close
+}
+
+func flatCandles(n int, price float64) [][2]float64 {
+	bodies := make([][2]float64, n)
+	for i := range bodies {
+		bodies[i] = [2]float64{price, price + 1}
+	}
+	return bodies
+}
+
+func TestCandlestickEngulfing(t *testing.T) {
+	bodies := append(flatCandles(candleAveragePeriod, 100), [2]float64{102, 100}, [2]float64{99, 103})
+	open, high, low, close := testCandles(bodies)
+
+	pattern := CandlestickPattern("CDLENGULFING", open, high, low, close)
+	if pattern[len(pattern)-1] != 100 {
+		t.Errorf("Expected bullish engulfing on last candle; got: %.0f", pattern[len(pattern)-1])
+	}
+
+	bodies = append(flatCandles(candleAveragePeriod, 100), [2]float64{100, 102}, [2]float64{103, 99})
+	open, high, low, close = testCandles(bodies)
+
+	pattern = CandlestickPattern("CDLENGULFING", open, high, low, close)
+	if pattern[len(pattern)-1] != -100 {
+		t.Errorf("Expected bearish engulfing on last candle; got: %.0f", pattern[len(pattern)-1])
+	}
+}
+
+func TestCandlestickMorningStar(t *testing.T) {
+	bodies := append(flatCandles(candleAveragePeriod, 100), [2]float64{104, 100}, [2]float64{99, 99.2}, [2]float64{100, 103})
+	open, high, low, close := testCandles(bodies)
+
+	pattern := CandlestickPattern("CDLMORNINGSTAR", open, high, low, close)
+	if pattern[len(pattern)-1] != 100 {
+		t.Errorf("Expected morning star on last candle; got: %.0f", pattern[len(pattern)-1])
+	}
+}
+
+func TestCandlestickUnknownPattern(t *testing.T) {
+	if CandlestickPattern("CDLUNKNOWN", nil, nil, nil, nil) != nil {
+		t.Error("Expected nil for unknown candlestick pattern")
+	}
+}
@@ -12,6 +12,10 @@ func (b BuyCondition) GetIndicatorPeriod() int {
 	return b.IndicatorPeriod
 }
 
+func (b BuyCondition) GetIndicatorSource() PriceSource {
+	return b.IndicatorSource
+}
+
 func (b BuyCondition) GetCheckValue() Indicator {
 	return b.IndicatorCheckValue
 }
@@ -28,6 +32,10 @@ func (s SellCondition) GetIndicatorPeriod() int {
 	return s.IndicatorPeriod
 }
 
+func (s SellCondition) GetIndicatorSource() PriceSource {
+	return s.IndicatorSource
+}
+
 func (s SellCondition) GetCheckValue() Indicator {
 	return s.IndicatorCheckValue
 }
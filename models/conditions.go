@@ -4,6 +4,7 @@ type BuyCondition struct {
 	IndicatorName       string        `bigquery:"indicator_name"`
 	IndicatorType       IndicatorType `bigquery:"indicator_type"`
 	IndicatorPeriod     int           `bigquery:"indicator_period"`
+	IndicatorSource     PriceSource   `bigquery:"indicator_source"`
 	IndicatorCheckValue Indicator     `bigquery:"indicator_check_value"`
 }
 
@@ -16,6 +17,12 @@ type ConditionType int64
 const (
 	SellPercentage ConditionType = 1
 	SellIndicator  ConditionType = 2
+	// SellATR uses ProfitThreshold and LossThreshold as multiples of the ATR(IndicatorPeriod)
+	// captured when the position was opened
+	SellATR ConditionType = 3
+	// SellEndOfSession closes the position at the last bar of the trading session, whatever the
+	// other conditions say, so no position is held overnight
+	SellEndOfSession ConditionType = 4
 )
 
 type SellCondition struct {
@@ -25,9 +32,20 @@ type SellCondition struct {
 	IndicatorName       string        `bigquery:"indicator_name"`
 	IndicatorType       IndicatorType `bigquery:"indicator_type"`
 	IndicatorPeriod     int           `bigquery:"indicator_period"`
+	IndicatorSource     PriceSource   `bigquery:"indicator_source"`
 	IndicatorCheckValue Indicator     `bigquery:"indicator_check_value"`
 }
 
 type SellScenario struct {
 	Conditions []SellCondition
 }
+
+// ExitsAtSessionEnd reports whether positions are closed at the end of each trading session
+func (s SellScenario) ExitsAtSessionEnd() bool {
+	for _, condition := range s.Conditions {
+		if condition.ConditionType == SellEndOfSession {
+			return true
+		}
+	}
+	return false
+}
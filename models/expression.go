@@ -0,0 +1,437 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/markcheno/go-talib"
+)
+
+// CustomIndicator is a named series defined by an expression in scenarios.json, for example
+// "(close - sma(close,20)) / stddev(close,20)". Once calculated it can be used by name like
+// any other indicator in the conditions of the scenario.
+type CustomIndicator struct {
+	Name       string `bigquery:"name"`
+	Expression string `bigquery:"expression"`
+}
+
+// Functions taking a series and a period, like sma(close, 20)
+var seriesFunctions = map[string]func(in []float64, period int) []float64{
+	"sma":  talib.Sma,
+	"ema":  talib.Ema,
+	"wma":  talib.Wma,
+	"dema": talib.Dema,
+	"tema": talib.Tema,
+	"rsi":  talib.Rsi,
+	"cmo":  talib.Cmo,
+	"roc":  talib.Roc,
+	"mom":  talib.Mom,
+	"trix": talib.Trix,
+	"max":  talib.Max,
+	"min":  talib.Min,
+	"sum":  talib.Sum,
+	"var":  talib.Var,
+	"stddev": func(in []float64, period int) []float64 {
+		return talib.StdDev(in, period, 1)
+	},
+	"linearreg": talib.LinearReg,
+}
+
+// Functions calculated from the candles and a period, like atr(14)
+var candleFunctions = map[string]func(data []IntradayData, period int) []float64{
+	"atr": func(data []IntradayData, period int) []float64 {
+		return talib.Atr(PriceSeries(data, SourceHigh), PriceSeries(data, SourceLow), PriceSeries(data, SourceClose), period)
+	},
+	"natr": func(data []IntradayData, period int) []float64 {
+		return talib.Natr(PriceSeries(data, SourceHigh), PriceSeries(data, SourceLow), PriceSeries(data, SourceClose), period)
+	},
+	"adx": func(data []IntradayData, period int) []float64 {
+		return talib.Adx(PriceSeries(data, SourceHigh), PriceSeries(data, SourceLow), PriceSeries(data, SourceClose), period)
+	},
+	"cci": func(data []IntradayData, period int) []float64 {
+		return talib.Cci(PriceSeries(data, SourceHigh), PriceSeries(data, SourceLow), PriceSeries(data, SourceClose), period)
+	},
+	"willr": func(data []IntradayData, period int) []float64 {
+		return talib.WillR(PriceSeries(data, SourceHigh), PriceSeries(data, SourceLow), PriceSeries(data, SourceClose), period)
+	},
+	"mfi": func(data []IntradayData, period int) []float64 {
+		return talib.Mfi(PriceSeries(data, SourceHigh), PriceSeries(data, SourceLow), PriceSeries(data, SourceClose), PriceSeries(data, SourceVolume), period)
+	},
+	"rvol": func(data []IntradayData, period int) []float64 {
+		return RelativeVolume(PriceSeries(data, SourceVolume), period)
+	},
+}
+
+// Price sources that can be used as variables in an expression
+var expressionSources = map[string]PriceSource{
+	"open":    SourceOpen,
+	"high":    SourceHigh,
+	"low":     SourceLow,
+	"close":   SourceClose,
+	"volume":  SourceVolume,
+	"hl2":     SourceHL2,
+	"hlc3":    SourceHLC3,
+	"typical": SourceTypical,
+	"ohlc4":   SourceOHLC4,
+}
+
+// Expression is a parsed custom indicator formula
+type Expression struct {
+	root expressionNode
+}
+
+type expressionNode interface {
+	evaluate(data []IntradayData, named map[string][]float64) ([]float64, error)
+}
+
+type numberNode struct {
+	value float64
+}
+
+type variableNode struct {
+	name string
+}
+
+type callNode struct {
+	name   string
+	args   []expressionNode
+	period int
+}
+
+type negateNode struct {
+	operand expressionNode
+}
+
+type binaryNode struct {
+	operator    byte
+	left, right expressionNode
+}
+
+// ParseExpression parses formulas made of numbers, price sources, custom indicator names,
+// + - * / and parentheses and calls to the functions in seriesFunctions and candleFunctions
+func ParseExpression(input string) (*Expression, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.position < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at end of expression", p.tokens[p.position])
+	}
+
+	return &Expression{root: root}, nil
+}
+
+// Evaluate calculates the expression for every candle. Named holds the series of previously
+// calculated custom indicators that the expression can refer to.
+func (e *Expression) Evaluate(data []IntradayData, named map[string][]float64) ([]float64, error) {
+	return e.root.evaluate(data, named)
+}
+
+// CheckVariables returns an error for the first variable that is neither a price source nor one of
+// the named series, so a formula that can't be evaluated is caught before it runs
+func (e *Expression) CheckVariables(named map[string]bool) error {
+	return checkVariables(e.root, named)
+}
+
+func checkVariables(node expressionNode, named map[string]bool) error {
+	switch n := node.(type) {
+	case variableNode:
+		if _, isSource := expressionSources[strings.ToLower(n.name)]; !isSource && !named[n.name] {
+			return fmt.Errorf("unknown series %q", n.name)
+		}
+	case callNode:
+		for _, arg := range n.args {
+			if err := checkVariables(arg, named); err != nil {
+				return err
+			}
+		}
+	case negateNode:
+		return checkVariables(n.operand, named)
+	case binaryNode:
+		if err := checkVariables(n.left, named); err != nil {
+			return err
+		}
+		return checkVariables(n.right, named)
+	}
+	return nil
+}
+
+func tokenize(input string) ([]string, error) {
+	var tokens []string
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/(),", r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected character %q in expression", r)
+		}
+	}
+	return tokens, nil
+}
+
+type expressionParser struct {
+	tokens   []string
+	position int
+}
+
+func (p *expressionParser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+	return ""
+}
+
+func (p *expressionParser) next() string {
+	token := p.peek()
+	p.position++
+	return token
+}
+
+func (p *expressionParser) expect(token string) error {
+	if next := p.next(); next != token {
+		return fmt.Errorf("expected %q but got %q", token, next)
+	}
+	return nil
+}
+
+// sum := product (('+' | '-') product)*
+func (p *expressionParser) parseSum() (expressionNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		operator := p.next()[0]
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{operator: operator, left: left, right: right}
+	}
+	return left, nil
+}
+
+// product := unary (('*' | '/') unary)*
+func (p *expressionParser) parseProduct() (expressionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" {
+		operator := p.next()[0]
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{operator: operator, left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary := '-' unary | primary
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	if p.peek() == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negateNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// primary := number | name | name '(' arguments ')' | '(' sum ')'
+func (p *expressionParser) parsePrimary() (expressionNode, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token)
+		}
+		return numberNode{value: value}, nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		if p.peek() == "(" {
+			return p.parseCall(token)
+		}
+		return variableNode{name: token}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", token)
+}
+
+func (p *expressionParser) parseCall(name string) (expressionNode, error) {
+	p.next()
+
+	var args []expressionNode
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	call := callNode{name: strings.ToLower(name)}
+	switch {
+	case call.name == "abs":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("abs takes 1 argument, got %d", len(args))
+		}
+		call.args = args
+		return call, nil
+	case seriesFunctions[call.name] != nil:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s takes a series and a period, got %d arguments", name, len(args))
+		}
+		call.args = args[:1]
+		args = args[1:]
+	case candleFunctions[call.name] != nil:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a period, got %d arguments", name, len(args))
+		}
+	default:
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	// The period has to be a whole positive number
+	period, isNumber := args[0].(numberNode)
+	if !isNumber || period.value < 1 || period.value != float64(int(period.value)) {
+		return nil, fmt.Errorf("period of %s must be a positive whole number", name)
+	}
+	call.period = int(period.value)
+
+	return call, nil
+}
+
+func (n numberNode) evaluate(data []IntradayData, named map[string][]float64) ([]float64, error) {
+	out := make([]float64, len(data))
+	for i := range out {
+		out[i] = n.value
+	}
+	return out, nil
+}
+
+func (n variableNode) evaluate(data []IntradayData, named map[string][]float64) ([]float64, error) {
+	if series, exists := named[n.name]; exists {
+		return series, nil
+	}
+	if source, exists := expressionSources[strings.ToLower(n.name)]; exists {
+		return PriceSeries(data, source), nil
+	}
+	return nil, fmt.Errorf("unknown series %q", n.name)
+}
+
+func (n callNode) evaluate(data []IntradayData, named map[string][]float64) ([]float64, error) {
+	// Too few bars for the period, the whole series is still warming up
+	warmingUp := len(data) < RequiredBars(n.name, n.period)
+
+	if function, exists := candleFunctions[n.name]; exists {
+		if warmingUp {
+			return make([]float64, len(data)), nil
+		}
+		return function(data, n.period), nil
+	}
+
+	in, err := n.args[0].evaluate(data, named)
+	if err != nil {
+		return nil, err
+	}
+	if warmingUp {
+		return make([]float64, len(in)), nil
+	}
+
+	if n.name == "abs" {
+		out := make([]float64, len(in))
+		for i, value := range in {
+			if value < 0 {
+				value = -value
+			}
+			out[i] = value
+		}
+		return out, nil
+	}
+
+	return seriesFunctions[n.name](in, n.period), nil
+}
+
+func (n negateNode) evaluate(data []IntradayData, named map[string][]float64) ([]float64, error) {
+	in, err := n.operand.evaluate(data, named)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]float64, len(in))
+	for i, value := range in {
+		out[i] = -value
+	}
+	return out, nil
+}
+
+func (n binaryNode) evaluate(data []IntradayData, named map[string][]float64) ([]float64, error) {
+	left, err := n.left.evaluate(data, named)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.evaluate(data, named)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]float64, len(left))
+	for i := range out {
+		switch n.operator {
+		case '+':
+			out[i] = left[i] + right[i]
+		case '-':
+			out[i] = left[i] - right[i]
+		case '*':
+			out[i] = left[i] * right[i]
+		case '/':
+			// Dividing by zero (like a stddev during warm-up) gives 0 so conditions don't fire on it
+			if right[i] != 0 {
+				out[i] = left[i] / right[i]
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func expressionTestData(closes ...float64) []IntradayData {
+	data := make([]IntradayData, len(closes))
+	for i, close := range closes {
+		data[i] = IntradayData{Open: close, High: close + 1, Low: close - 1, Close: close, Volume: 100}
+	}
+	return data
+}
+
+func TestExpressionArithmetic(t *testing.T) {
+	expression, err := ParseExpression("(high + low) / 2 - -close * 2")
+	if err != nil {
+		t.Fatalf("ParseExpression should not give error; got: %s", err.Error())
+	}
+
+	series, err := expression.Evaluate(expressionTestData(10, 20), nil)
+	if err != nil {
+		t.Fatalf("Evaluate should not give error; got: %s", err.Error())
+	}
+
+	if series[0] != 30 || series[1] != 60 {
+		t.Errorf("Expected [30 60]; got: %v", series)
+	}
+}
+
+func TestExpressionFunctionsAndNamedSeries(t *testing.T) {
+	data := expressionTestData(1, 2, 3, 4, 5)
+
+	expression, err := ParseExpression("(close - sma(close, 3)) / stddev(close, 3)")
+	if err != nil {
+		t.Fatalf("ParseExpression should not give error; got: %s", err.Error())
+	}
+	zscore, err := expression.Evaluate(data, nil)
+	if err != nil {
+		t.Fatalf("Evaluate should not give error; got: %s", err.Error())
+	}
+
+	expected := 1 / math.Sqrt(2.0/3)
+	if math.Abs(zscore[4]-expected) > 1e-9 {
+		t.Errorf("Expected z-score %.4f; got: %.4f", expected, zscore[4])
+	}
+	if zscore[0] != 0 {
+		t.Errorf("Expected 0 while stddev is warming up; got: %.4f", zscore[0])
+	}
+
+	expression, err = ParseExpression("zscore * 2")
+	if err != nil {
+		t.Fatalf("ParseExpression should not give error; got: %s", err.Error())
+	}
+	doubled, err := expression.Evaluate(data, map[string][]float64{"zscore": zscore})
+	if err != nil {
+		t.Fatalf("Evaluate should not give error; got: %s", err.Error())
+	}
+	if doubled[4] != 2*zscore[4] {
+		t.Errorf("Expected named series to be used; got: %.4f", doubled[4])
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"close +",
+		"sma(close)",
+		"sma(close, 2.5)",
+		"sma(close, period)",
+		"unknown(close, 3)",
+		"(close",
+		"close $ 2",
+	}
+	for _, input := range invalid {
+		if _, err := ParseExpression(input); err == nil {
+			t.Errorf("ParseExpression(%q) should give error but didn't get any", input)
+		}
+	}
+
+	expression, err := ParseExpression("missing + 1")
+	if err != nil {
+		t.Fatalf("ParseExpression should not give error; got: %s", err.Error())
+	}
+	if _, err := expression.Evaluate(expressionTestData(1), nil); err == nil {
+		t.Error("Evaluate should give error for unknown series but didn't get any")
+	}
+}
+
+func TestExpressionCheckVariables(t *testing.T) {
+	expression, _ := ParseExpression("abs(zscore - sma(HLC3, 3)) + missing")
+
+	if err := expression.CheckVariables(map[string]bool{"zscore": true, "missing": true}); err != nil {
+		t.Errorf("CheckVariables should not give error for known series; got: %s", err.Error())
+	}
+	if err := expression.CheckVariables(map[string]bool{"zscore": true}); err == nil {
+		t.Errorf("CheckVariables should give error for missing but didn't get any")
+	}
+}
+
+func TestExpressionFunctionsWithFewerBarsThanThePeriod(t *testing.T) {
+	expressions := []string{}
+	for name := range seriesFunctions {
+		expressions = append(expressions, name+"(close, 5)")
+	}
+	for name := range candleFunctions {
+		expressions = append(expressions, name+"(5)")
+	}
+
+	for _, formula := range expressions {
+		expression, err := ParseExpression(formula)
+		if err != nil {
+			t.Fatalf("ParseExpression should not give error for %s; got: %s", formula, err.Error())
+		}
+		for bars := 0; bars <= 17; bars++ {
+			series, err := expression.Evaluate(expressionTestData(make([]float64, bars)...), nil)
+			if err != nil || len(series) != bars {
+				t.Errorf("Expected %d values of %s; got: %d, %v", bars, formula, len(series), err)
+			}
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package models
 
-import "github.com/markcheno/go-talib"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/markcheno/go-talib"
+)
 
 type Indicator struct {
 	IndicatorName     string
 	IndicatorPeriod   int
 	IndicatorStrength float64
+	IndicatorSource   PriceSource
 }
 
 type IndicatorType int64
@@ -15,73 +22,265 @@ const (
 	IndicatorUnder     IndicatorType = 2
 	IndicatorCrossUp   IndicatorType = 3
 	IndicatorCrossDown IndicatorType = 4
+	// Candlestick pattern conditions, the indicator name is one of CandlestickPatterns
+	IndicatorPatternBullish IndicatorType = 5
+	IndicatorPatternBearish IndicatorType = 6
 )
 
 type IndicatorCondition interface {
 	GetIndicatorName() string
 	GetIndicatorType() IndicatorType
 	GetIndicatorPeriod() int
+	GetIndicatorSource() PriceSource
 	GetCheckValue() Indicator
 }
 
 type IndicatorKey struct {
 	Name   string
 	Period int
+	Source PriceSource
+}
+
+// Indicators that are calculated without a period, like the session VWAP
+var periodlessIndicators = map[string]bool{
+	"Data":       true,
+	"OBV":        true,
+	"AD":         true,
+	"VWAP":       true,
+	"VWAP_UPPER": true,
+	"VWAP_LOWER": true,
+}
+
+// UsesSeries tells whether the check value refers to an indicator series rather than a
+// fixed IndicatorStrength (Used by RSI/WILLR etc.)
+func (i Indicator) UsesSeries() bool {
+	return i.IndicatorPeriod > 0 || periodlessIndicators[i.IndicatorName]
 }
 
-func GetPredefinedIndicators(buyScenario BuyScenario, sellScenario SellScenario, data []IntradayData) map[IndicatorKey][]float64 {
+// RequiredBars is the number of bars go-talib needs to calculate the indicator (or expression function)
+// without panicking, zero for indicators that can be calculated on any number of bars
+func RequiredBars(name string, period int) int {
+	switch strings.ToUpper(name) {
+	case "OBV":
+		return 1
+	case "SMA", "VOLSMA", "EMA", "WMA", "SUM", "VAR", "STDDEV", "LINEARREG", "CCI", "WILLR", "AROONOSC", "MAX", "MIN":
+		return max(period, 1)
+	case "RSI", "CMO", "ROC", "MOM", "ATR", "NATR", "MFI", "PLUS_DI", "MINUS_DI":
+		return period + 1
+	case "ADX", "DEMA":
+		return 2 * max(period, 1)
+	case "TEMA", "TRIX":
+		return 3 * max(period, 1)
+	}
+	return 0
+}
+
+// String names the indicator like SMA(20), RSI(14,hlc3) or VWAP
+func (k IndicatorKey) String() string {
+	args := []string{}
+	if k.Period > 0 {
+		args = append(args, strconv.Itoa(k.Period))
+	}
+	if k.Source != "" {
+		args = append(args, string(k.Source))
+	}
+	if len(args) == 0 {
+		return k.Name
+	}
+	return fmt.Sprintf("%s(%s)", k.Name, strings.Join(args, ","))
+}
+
+// Whether the check value of the condition refers to a series, any check value with a name except
+// a fixed value named like the indicator of the condition (Used by RSI/WILLR etc.)
+func checkValueUsesSeries(condition IndicatorCondition) bool {
+	checkValue := condition.GetCheckValue()
+	if checkValue.IndicatorName == "" {
+		return false
+	}
+	return checkValue.UsesSeries() || checkValue.IndicatorName != condition.GetIndicatorName()
+}
+
+// GetPredefinedIndicators calculates the indicators used by the scenarios, sessionStarts marks the
+// bars where session indicators like the VWAP reset. It fails when a custom indicator can't be
+// calculated or a condition refers to an indicator that isn't known.
+func GetPredefinedIndicators(buyScenario BuyScenario, sellScenario SellScenario, customIndicators []CustomIndicator, data []IntradayData, sessionStarts []bool) (map[IndicatorKey][]float64, error) {
+	openPrices := make([]float64, len(data))
 	highPrices := make([]float64, len(data))
 	lowPrices := make([]float64, len(data))
 	closePrices := make([]float64, len(data))
+	volumes := make([]float64, len(data))
 	for i, entry := range data {
+		openPrices[i] = entry.Open
 		highPrices[i] = entry.High
 		lowPrices[i] = entry.Low
 		closePrices[i] = entry.Close
+		volumes[i] = float64(entry.Volume)
 	}
 
 	cache := make(map[IndicatorKey][]float64)
 
-	processCondition := func(name string, period int) {
-		key := IndicatorKey{Name: name, Period: period}
+	sources := make(map[PriceSource][]float64)
+	sourceSeries := func(source PriceSource) []float64 {
+		if source == "" {
+			return closePrices
+		}
+		if _, exists := sources[source]; !exists {
+			sources[source] = PriceSeries(data, source)
+		}
+		return sources[source]
+	}
+
+	// The session VWAP is shared by the VWAP line and its bands, so it is only calculated once
+	var vwap, vwapDeviation []float64
+	calculateVWAP := func() {
+		if vwap == nil {
+			vwap, vwapDeviation = VWAP(data, sessionStarts)
+		}
+	}
+
+	// Source only applies to indicators calculated from a single series (SMA, RSI, CMO and Data)
+	processCondition := func(name string, period int, source PriceSource) {
+		key := NewIndicatorKey(name, period, source)
 		if _, exists := cache[key]; exists {
 			return
 		}
+		input := sourceSeries(key.Source)
+
+		// go-talib panics on fewer bars than it needs, the whole series is still warming up then
+		if len(data) < RequiredBars(name, period) {
+			cache[key] = make([]float64, len(data))
+			return
+		}
 
 		switch name {
 		case "SMA":
-			cache[key] = talib.Sma(closePrices, period)
+			cache[key] = talib.Sma(input, period)
 		case "RSI":
-			cache[key] = talib.Rsi(closePrices, period)
+			cache[key] = talib.Rsi(input, period)
 		case "WILLR":
 			cache[key] = talib.WillR(highPrices, lowPrices, closePrices, period)
+		case "OBV":
+			cache[key] = talib.Obv(closePrices, volumes)
+		case "MFI":
+			cache[key] = talib.Mfi(highPrices, lowPrices, closePrices, volumes, period)
+		case "AD":
+			cache[key] = talib.Ad(highPrices, lowPrices, closePrices, volumes)
+		case "VOLSMA":
+			cache[key] = talib.Sma(volumes, period)
+		case "RVOL":
+			cache[key] = RelativeVolume(volumes, period)
+		case "VWAP":
+			calculateVWAP()
+			cache[key] = vwap
+		case "VWAP_UPPER", "VWAP_LOWER":
+			// Period is the band width in standard deviations, defaulting to 1
+			multiplier := float64(period)
+			if multiplier == 0 {
+				multiplier = 1
+			}
+			if name == "VWAP_LOWER" {
+				multiplier = -multiplier
+			}
+			calculateVWAP()
+			cache[key] = VWAPBand(vwap, vwapDeviation, multiplier)
+		case "ATR":
+			cache[key] = talib.Atr(highPrices, lowPrices, closePrices, period)
+		case "NATR":
+			cache[key] = talib.Natr(highPrices, lowPrices, closePrices, period)
+		case "ADX":
+			cache[key] = talib.Adx(highPrices, lowPrices, closePrices, period)
+		case "PLUS_DI":
+			cache[key] = talib.PlusDI(highPrices, lowPrices, closePrices, period)
+		case "MINUS_DI":
+			cache[key] = talib.MinusDI(highPrices, lowPrices, closePrices, period)
+		case "AROONOSC":
+			cache[key] = talib.AroonOsc(highPrices, lowPrices, period)
+		case "CCI":
+			cache[key] = talib.Cci(highPrices, lowPrices, closePrices, period)
+		case "CMO":
+			cache[key] = talib.Cmo(input, period)
+		case "SUPERTREND", "SUPERTREND_DIR", "SUPERTREND_FLIP":
+			// All SuperTrend series come from the same calculation
+			line, direction, flip := SuperTrend(highPrices, lowPrices, closePrices, period)
+			cache[IndicatorKey{Name: "SUPERTREND", Period: period, Source: key.Source}] = line
+			cache[IndicatorKey{Name: "SUPERTREND_DIR", Period: period, Source: key.Source}] = direction
+			cache[IndicatorKey{Name: "SUPERTREND_FLIP", Period: period, Source: key.Source}] = flip
 		case "Data":
-			cache[key] = closePrices
+			cache[key] = input
+		default:
+			if _, isPattern := CandlestickPatterns[name]; isPattern {
+				cache[key] = CandlestickPattern(name, openPrices, highPrices, lowPrices, closePrices)
+			}
 		}
 	}
 
+	// Custom indicators are calculated first in the order they are defined, so they can refer to each other
+	named := make(map[string][]float64)
+	for _, custom := range customIndicators {
+		expression, err := ParseExpression(custom.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom indicator %s: %v", custom.Name, err)
+		}
+
+		series, err := expression.Evaluate(data, named)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate custom indicator %s: %v", custom.Name, err)
+		}
+
+		named[custom.Name] = series
+		cache[NewIndicatorKey(custom.Name, 0, "")] = series
+	}
+
 	// Loop through all buy conditions
 	for _, cond := range buyScenario.Conditions {
-		processCondition(cond.IndicatorName, cond.IndicatorPeriod)
+		processCondition(cond.IndicatorName, cond.IndicatorPeriod, cond.IndicatorSource)
 
 		cv := cond.IndicatorCheckValue
-		if cv.IndicatorName != "" && cv.IndicatorPeriod > 0 {
-			processCondition(cv.IndicatorName, cv.IndicatorPeriod)
+		if cv.UsesSeries() {
+			processCondition(cv.IndicatorName, cv.IndicatorPeriod, cv.IndicatorSource)
 		}
 	}
 
 	// Loop through all sell conditions
 	for _, cond := range sellScenario.Conditions {
+		if cond.ConditionType == SellATR {
+			processCondition("ATR", cond.IndicatorPeriod, "")
+			continue
+		}
+
 		if cond.ConditionType != SellIndicator {
 			continue
 		}
 
-		processCondition(cond.IndicatorName, cond.IndicatorPeriod)
+		processCondition(cond.IndicatorName, cond.IndicatorPeriod, cond.IndicatorSource)
 
 		cv := cond.IndicatorCheckValue
-		if cv.IndicatorName != "" && cv.IndicatorPeriod > 0 {
-			processCondition(cv.IndicatorName, cv.IndicatorPeriod)
+		if cv.UsesSeries() {
+			processCondition(cv.IndicatorName, cv.IndicatorPeriod, cv.IndicatorSource)
+		}
+	}
+
+	// Every condition and check value series must be calculated, a missing one is an unknown indicator
+	conditions := []IndicatorCondition{}
+	for _, cond := range buyScenario.Conditions {
+		conditions = append(conditions, cond)
+	}
+	for _, cond := range sellScenario.Conditions {
+		if cond.ConditionType == SellIndicator {
+			conditions = append(conditions, cond)
+		}
+	}
+	for _, cond := range conditions {
+		keys := []IndicatorKey{ConditionKey(cond)}
+		if checkValueUsesSeries(cond) {
+			keys = append(keys, cond.GetCheckValue().Key())
+		}
+		for _, key := range keys {
+			if _, exists := cache[key]; !exists {
+				return nil, fmt.Errorf("unknown indicator %q", key.String())
+			}
 		}
 	}
 
-	return cache
+	return cache, nil
 }
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetPredefinedIndicatorsUnknownIndicator(t *testing.T) {
+	data := expressionTestData(1, 2, 3, 4, 5)
+
+	tests := []struct {
+		name     string
+		buy      BuyScenario
+		custom   []CustomIndicator
+		expected string
+	}{
+		{"unknown indicator", BuyScenario{Conditions: []BuyCondition{{IndicatorName: "UNKNOWN", IndicatorPeriod: 3}}}, nil, "UNKNOWN(3)"},
+		{"unknown check value", BuyScenario{Conditions: []BuyCondition{{IndicatorName: "Data", IndicatorCheckValue: Indicator{IndicatorName: "spread"}}}}, nil, "spread"},
+		{"custom indicator that can't be calculated", BuyScenario{}, []CustomIndicator{{Name: "broken", Expression: "missing + 1"}}, "missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := GetPredefinedIndicators(test.buy, SellScenario{}, test.custom, data, nil)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error naming %s; got: %v", test.expected, err)
+			}
+		})
+	}
+
+	// Check values named like the indicator are fixed values, and custom indicators are series by name
+	buy := BuyScenario{Conditions: []BuyCondition{
+		{IndicatorName: "RSI", IndicatorPeriod: 2, IndicatorCheckValue: Indicator{IndicatorName: "RSI", IndicatorStrength: 30}},
+		{IndicatorName: "Data", IndicatorCheckValue: Indicator{IndicatorName: "spread"}},
+	}}
+	custom := []CustomIndicator{{Name: "spread", Expression: "high - low"}}
+	if _, err := GetPredefinedIndicators(buy, SellScenario{}, custom, data, nil); err != nil {
+		t.Errorf("GetPredefinedIndicators should not give error; got: %s", err.Error())
+	}
+}
+
+func TestIndicatorsWithFewerBarsThanThePeriod(t *testing.T) {
+	names := []string{"SMA", "RSI", "WILLR", "OBV", "MFI", "AD", "VOLSMA", "RVOL", "VWAP", "ATR", "NATR", "ADX", "PLUS_DI", "MINUS_DI", "AROONOSC", "CCI", "CMO", "SUPERTREND", "Data", "CDLDOJI"}
+
+	for _, period := range []int{1, 3, 14} {
+		buy := BuyScenario{}
+		for _, name := range names {
+			buy.Conditions = append(buy.Conditions, BuyCondition{IndicatorName: name, IndicatorPeriod: period})
+		}
+
+		// From no bars up to more than the longest warm-up, without panicking
+		for bars := 0; bars <= 3*period+2; bars++ {
+			data := expressionTestData(make([]float64, bars)...)
+			cache, err := GetPredefinedIndicators(buy, SellScenario{}, nil, data, nil)
+			if err != nil {
+				t.Fatalf("GetPredefinedIndicators should not give error for %d bars; got: %s", bars, err.Error())
+			}
+			for _, condition := range buy.Conditions {
+				if series := cache[ConditionKey(condition)]; len(series) != bars {
+					t.Errorf("Expected %d values of %s(%d); got: %d", bars, condition.IndicatorName, period, len(series))
+				}
+			}
+		}
+	}
+
+	// SMA(200) on 50 daily bars is still warming up
+	data := expressionTestData(make([]float64, 50)...)
+	for i := range data {
+		data[i].Close = 100
+	}
+	buy := BuyScenario{Conditions: []BuyCondition{{IndicatorName: "SMA", IndicatorPeriod: 200}}}
+	cache, _ := GetPredefinedIndicators(buy, SellScenario{}, nil, data, nil)
+	for _, value := range cache[ConditionKey(buy.Conditions[0])] {
+		if value != 0 {
+			t.Fatalf("Expected an all zero warm-up series; got: %v", cache[ConditionKey(buy.Conditions[0])])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import "strings"
+
+// PriceSource selects which price series of the candles an indicator is calculated from
+type PriceSource string
+
+const (
+	SourceOpen    PriceSource = "open"
+	SourceHigh    PriceSource = "high"
+	SourceLow     PriceSource = "low"
+	SourceClose   PriceSource = "close"
+	SourceVolume  PriceSource = "volume"
+	SourceHL2     PriceSource = "hl2"     // (high + low) / 2
+	SourceHLC3    PriceSource = "hlc3"    // Typical price, (high + low + close) / 3
+	SourceTypical PriceSource = "typical" // Same as hlc3
+	SourceOHLC4   PriceSource = "ohlc4"   // (open + high + low + close) / 4
+)
+
+// Valid reports whether the source is known, empty being the close
+func (s PriceSource) Valid() bool {
+	switch PriceSource(strings.ToLower(string(s))) {
+	case "", SourceOpen, SourceHigh, SourceLow, SourceClose, SourceVolume, SourceHL2, SourceHLC3, SourceTypical, SourceOHLC4:
+		return true
+	}
+	return false
+}
+
+// NewIndicatorKey builds the cache key for an indicator, close being the default source
+func NewIndicatorKey(name string, period int, source PriceSource) IndicatorKey {
+	source = PriceSource(strings.ToLower(string(source)))
+	if source == SourceClose {
+		source = ""
+	}
+	if source == SourceTypical {
+		source = SourceHLC3
+	}
+	return IndicatorKey{Name: name, Period: period, Source: source}
+}
+
+// Key is the cache key of the indicator used as check value
+func (i Indicator) Key() IndicatorKey {
+	return NewIndicatorKey(i.IndicatorName, i.IndicatorPeriod, i.IndicatorSource)
+}
+
+// ConditionKey is the cache key of the indicator a condition is checking
+func ConditionKey(condition IndicatorCondition) IndicatorKey {
+	return NewIndicatorKey(condition.GetIndicatorName(), condition.GetIndicatorPeriod(), condition.GetIndicatorSource())
+}
+
+// PriceSeries returns the chosen source for every candle, the close when no source is given. Sources
+// are checked with Valid when scenarios and requests are parsed.
+func PriceSeries(data []IntradayData, source PriceSource) []float64 {
+	out := make([]float64, len(data))
+	for i, entry := range data {
+		switch PriceSource(strings.ToLower(string(source))) {
+		case SourceOpen:
+			out[i] = entry.Open
+		case SourceHigh:
+			out[i] = entry.High
+		case SourceLow:
+			out[i] = entry.Low
+		case SourceVolume:
+			out[i] = float64(entry.Volume)
+		case SourceHL2:
+			out[i] = (entry.High + entry.Low) / 2
+		case SourceHLC3, SourceTypical:
+			out[i] = (entry.High + entry.Low + entry.Close) / 3
+		case SourceOHLC4:
+			out[i] = (entry.Open + entry.High + entry.Low + entry.Close) / 4
+		default:
+			out[i] = entry.Close
+		}
+	}
+	return out
+}
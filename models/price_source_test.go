@@ -0,0 +1,72 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceSeries(t *testing.T) {
+	data := []IntradayData{{Open: 10, High: 14, Low: 8, Close: 12, Volume: 500}}
+
+	tests := []struct {
+		source   PriceSource
+		expected float64
+	}{
+		{SourceOpen, 10},
+		{SourceHigh, 14},
+		{SourceLow, 8},
+		{SourceClose, 12},
+		{SourceVolume, 500},
+		{SourceHL2, 11},
+		{SourceHLC3, 34.0 / 3},
+		{SourceTypical, 34.0 / 3},
+		{SourceOHLC4, 11},
+		{"HL2", 11},
+		{"", 12},
+		{"unknown", 12},
+	}
+
+	for _, test := range tests {
+		series := PriceSeries(data, test.source)
+		if math.Abs(series[0]-test.expected) > 1e-9 {
+			t.Errorf("Expected %.4f for source %q; got: %.4f", test.expected, test.source, series[0])
+		}
+	}
+}
+
+func TestPriceSourceValid(t *testing.T) {
+	for _, source := range []PriceSource{"", SourceClose, "HLC3", SourceTypical, SourceVolume} {
+		if !source.Valid() {
+			t.Errorf("Expected %q to be valid", source)
+		}
+	}
+	for _, source := range []PriceSource{"hcl3", "unknown"} {
+		if source.Valid() {
+			t.Errorf("Expected %q to be invalid", source)
+		}
+	}
+}
+
+func TestNewIndicatorKey(t *testing.T) {
+	tests := []struct {
+		source   PriceSource
+		expected PriceSource
+	}{
+		// Close is the default, so it shares the cache entry of indicators without a source
+		{SourceClose, ""},
+		{"CLOSE", ""},
+		{"", ""},
+		// Typical price is another name for hlc3
+		{SourceTypical, SourceHLC3},
+		{"HL2", SourceHL2},
+		{SourceOpen, SourceOpen},
+	}
+
+	for _, test := range tests {
+		key := NewIndicatorKey("SMA", 20, test.source)
+		expected := IndicatorKey{Name: "SMA", Period: 20, Source: test.expected}
+		if key != expected {
+			t.Errorf("Expected key %+v for source %q; got: %+v", expected, test.source, key)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+// QualityReport counts the problems found in the candles of a run and how they were repaired
+type QualityReport struct {
+	Candles           int  `json:"candles"` // Candles received from the data provider
+	OutOfOrder        int  `json:"out_of_order"`
+	Duplicates        int  `json:"duplicates"`
+	InvalidOHLC       int  `json:"invalid_ohlc"` // High below low, or open/close outside the high-low range
+	NonPositivePrices int  `json:"non_positive_prices"`
+	Spikes            int  `json:"spikes"`
+	MissingBars       int  `json:"missing_bars"`
+	Dropped           int  `json:"dropped"`
+	Filled            int  `json:"filled"`
+	Rejected          bool `json:"rejected"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 )
@@ -11,6 +12,14 @@ type ScenarioConfig struct {
 	Name                  string
 	IndicatorBuyScenario  BuyScenario
 	IndicatorSellScenario SellScenario
+	CustomIndicators      []CustomIndicator
+	Scorer                string // Name of the scoring model, the default one when empty
+	Interval              string // Bar interval the scenario is made for (1m, 5m, 1h, 1d), any interval when empty
+}
+
+// RunsOnInterval reports whether the scenario should run on bars of the interval
+func (s ScenarioConfig) RunsOnInterval(interval string) bool {
+	return s.Interval == "" || s.Interval == interval
 }
 
 // GetPredefinedScenarios returns a list of all predefined trading scenarios
@@ -36,5 +45,44 @@ func LoadScenarioConfigs(filepath string) ([]ScenarioConfig, error) {
 		return nil, err
 	}
 
+	// Catch invalid custom indicator formulas and price sources when loading rather than on every run
+	for _, scenario := range scenarios {
+		if err := validateSources(scenario); err != nil {
+			return nil, fmt.Errorf("scenario %s: %v", scenario.Name, err)
+		}
+		// A custom indicator can refer to the price sources and the custom indicators defined before it
+		named := make(map[string]bool)
+		for _, custom := range scenario.CustomIndicators {
+			expression, err := ParseExpression(custom.Expression)
+			if err == nil {
+				err = expression.CheckVariables(named)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("scenario %s: custom indicator %s: %v", scenario.Name, custom.Name, err)
+			}
+			named[custom.Name] = true
+		}
+	}
+
 	return scenarios, nil
 }
+
+// Checks the price source of every condition and check value of the scenario
+func validateSources(scenario ScenarioConfig) error {
+	conditions := []IndicatorCondition{}
+	for _, condition := range scenario.IndicatorBuyScenario.Conditions {
+		conditions = append(conditions, condition)
+	}
+	for _, condition := range scenario.IndicatorSellScenario.Conditions {
+		conditions = append(conditions, condition)
+	}
+
+	for _, condition := range conditions {
+		for _, source := range []PriceSource{condition.GetIndicatorSource(), condition.GetCheckValue().IndicatorSource} {
+			if !source.Valid() {
+				return fmt.Errorf("unknown price source %q of %s", source, condition.GetIndicatorName())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes the scenarios to a file and loads them
+func loadTestScenarios(t *testing.T, content string) ([]ScenarioConfig, error) {
+	path := filepath.Join(t.TempDir(), "scenarios.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadScenarioConfigs(path)
+}
+
+func TestLoadScenarioConfigs(t *testing.T) {
+	scenarios, err := loadTestScenarios(t, `[{"Name": "RSI", "IndicatorBuyScenario": {"Conditions": [
+		{"IndicatorName": "RSI", "IndicatorType": 2, "IndicatorPeriod": 14, "IndicatorSource": "hlc3", "IndicatorCheckValue": {"IndicatorName": "RSI", "IndicatorStrength": 30}}
+	]}}]`)
+	if err != nil {
+		t.Fatalf("LoadScenarioConfigs should not give error; got: %s", err.Error())
+	}
+	if len(scenarios) != 1 || scenarios[0].IndicatorBuyScenario.Conditions[0].IndicatorSource != SourceHLC3 {
+		t.Errorf("Expected 1 scenario on hlc3; got: %+v", scenarios)
+	}
+}
+
+func TestLoadScenarioConfigsUnknownSource(t *testing.T) {
+	_, err := loadTestScenarios(t, `[{"Name": "Typo", "IndicatorSellScenario": {"Conditions": [
+		{"ConditionType": 2, "IndicatorName": "SMA", "IndicatorType": 1, "IndicatorPeriod": 20, "IndicatorCheckValue": {"IndicatorName": "SMA", "IndicatorPeriod": 50, "IndicatorSource": "hcl3"}}
+	]}}]`)
+	if err == nil || !strings.Contains(err.Error(), "hcl3") {
+		t.Errorf("Expected error naming the unknown source; got: %v", err)
+	}
+}
+
+func TestLoadScenarioConfigsUnknownVariable(t *testing.T) {
+	// A custom indicator can use those defined before it, but not itself or later ones
+	_, err := loadTestScenarios(t, `[{"Name": "Custom", "CustomIndicators": [
+		{"Name": "spread", "Expression": "high - low"},
+		{"Name": "relative", "Expression": "spread / close"}
+	]}]`)
+	if err != nil {
+		t.Errorf("LoadScenarioConfigs should not give error; got: %s", err.Error())
+	}
+
+	_, err = loadTestScenarios(t, `[{"Name": "Custom", "CustomIndicators": [
+		{"Name": "relative", "Expression": "spread / close"},
+		{"Name": "spread", "Expression": "missing + 1"}
+	]}]`)
+	if err == nil || !strings.Contains(err.Error(), "spread") {
+		t.Errorf("Expected error naming the unknown series; got: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import "github.com/markcheno/go-talib"
+
+// Multiplier of the ATR used for the SuperTrend bands
+const superTrendMultiplier = 3.0
+
+// SuperTrend calculates the SuperTrend line using ATR(period) bands around the median price.
+// Direction is 1 while the trend is up and -1 while it is down, flip is 1 on the bar the trend
+// turns up, -1 on the bar it turns down and 0 otherwise. Values are 0 until the ATR is available.
+func SuperTrend(high, low, close []float64, period int) (line, direction, flip []float64) {
+	line = make([]float64, len(close))
+	direction = make([]float64, len(close))
+	flip = make([]float64, len(close))
+	if period <= 0 || len(close) <= period {
+		return line, direction, flip
+	}
+
+	atr := talib.Atr(high, low, close, period)
+
+	var finalUpper, finalLower float64
+	for i := period; i < len(close); i++ {
+		medianPrice := (high[i] + low[i]) / 2
+		basicUpper := medianPrice + superTrendMultiplier*atr[i]
+		basicLower := medianPrice - superTrendMultiplier*atr[i]
+
+		// First bar with an ATR starts the trend upwards
+		if i == period {
+			finalUpper, finalLower = basicUpper, basicLower
+			direction[i] = 1
+			line[i] = finalLower
+			continue
+		}
+
+		// Bands only move towards the price unless the price closed through them
+		if basicUpper < finalUpper || close[i-1] > finalUpper {
+			finalUpper = basicUpper
+		}
+		if basicLower > finalLower || close[i-1] < finalLower {
+			finalLower = basicLower
+		}
+
+		switch {
+		case direction[i-1] > 0 && close[i] < finalLower:
+			direction[i] = -1
+		case direction[i-1] < 0 && close[i] > finalUpper:
+			direction[i] = 1
+		default:
+			direction[i] = direction[i-1]
+		}
+
+		if direction[i] != direction[i-1] {
+			flip[i] = direction[i]
+		}
+
+		if direction[i] > 0 {
+			line[i] = finalLower
+		} else {
+			line[i] = finalUpper
+		}
+	}
+
+	return line, direction, flip
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+// Closes that rise steadily, crash, drift lower and rally, with high and low 1 around the close
+func superTrendCandles() (high, low, close []float64) {
+	close = []float64{10, 11, 12, 13, 14, 15, 16, 8, 7, 6, 5, 12, 15, 18}
+	for _, price := range close {
+		high = append(high, price+1)
+		low = append(low, price-1)
+	}
+	return high, low, close
+}
+
+func TestSuperTrend(t *testing.T) {
+	high, low, close := superTrendCandles()
+
+	line, direction, flip := SuperTrend(high, low, close, 3)
+
+	// The ATR(3) is 2 until the crash, so the lower band trails 6 below the close. The crash
+	// widens the ATR and the upper band then only moves down until the rally closes through it.
+	expectedLine := []float64{0, 0, 0, 7, 8, 9, 10, 19, 17.666667, 15.111111, 13.074074, 13.074074, 2.078189, 5.385460}
+	expectedDirection := []float64{0, 0, 0, 1, 1, 1, 1, -1, -1, -1, -1, -1, 1, 1}
+	expectedFlip := []float64{0, 0, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 1, 0}
+
+	for i := range close {
+		if math.Abs(line[i]-expectedLine[i]) > 1e-6 {
+			t.Errorf("Expected SuperTrend line %.6f at %d; got: %.6f", expectedLine[i], i, line[i])
+		}
+		if direction[i] != expectedDirection[i] {
+			t.Errorf("Expected SuperTrend direction %.0f at %d; got: %.0f", expectedDirection[i], i, direction[i])
+		}
+		if flip[i] != expectedFlip[i] {
+			t.Errorf("Expected SuperTrend flip %.0f at %d; got: %.0f", expectedFlip[i], i, flip[i])
+		}
+	}
+}
+
+func TestSuperTrendWithoutEnoughData(t *testing.T) {
+	high, low, close := superTrendCandles()
+
+	line, direction, flip := SuperTrend(high[:3], low[:3], close[:3], 3)
+	for i := range line {
+		if line[i] != 0 || direction[i] != 0 || flip[i] != 0 {
+			t.Errorf("Expected no SuperTrend values at %d without a full ATR period", i)
+		}
+	}
+}
@@ -1,13 +1,22 @@
 package models
 
 type Transaction struct {
-	TransactionID string  `bigquery:"transaction_id"`
-	TrendID       string  `bigquery:"trend_id"`
-	DateBought    string  `bigquery:"date_bought"`
-	DateSold      string  `bigquery:"date_sold"`
-	PriceBought   float64 `bigquery:"price_bought"`
-	PriceSold     float64 `bigquery:"price_sold"`
-	Volume        int64   `bigquery:"volume"`
+	TransactionID string  `bigquery:"transaction_id" json:"transaction_id"`
+	TrendID       string  `bigquery:"trend_id" json:"trend_id"`
+	DateBought    string  `bigquery:"date_bought" json:"date_bought"`
+	DateSold      string  `bigquery:"date_sold" json:"date_sold"`
+	PriceBought   float64 `bigquery:"price_bought" json:"price_bought"`
+	PriceSold     float64 `bigquery:"price_sold" json:"price_sold"`
+	Volume        int64   `bigquery:"volume" json:"volume"`
+
+	// Price path while the position was held, excursions are fractions of the buy price
+	HighestPrice          float64 `bigquery:"highest_price" json:"highest_price"`
+	LowestPrice           float64 `bigquery:"lowest_price" json:"lowest_price"`
+	MaxAdverseExcursion   float64 `bigquery:"max_adverse_excursion" json:"max_adverse_excursion"`
+	MaxFavorableExcursion float64 `bigquery:"max_favorable_excursion" json:"max_favorable_excursion"`
+	BarsHeld              int     `bigquery:"bars_held" json:"bars_held"`
+	EntryEfficiency       float64 `bigquery:"entry_efficiency" json:"entry_efficiency"` // 1 when bought at the lowest price
+	ExitEfficiency        float64 `bigquery:"exit_efficiency" json:"exit_efficiency"`   // 1 when sold at the highest price
 }
 
 type TransactionResponse struct {
@@ -18,4 +27,33 @@ type TransactionResponse struct {
 	PriceBought float64 `json:"price_bought"`
 	PriceSold   float64 `json:"price_sold"`
 	Volume      int64   `json:"volume"`
+
+	HighestPrice          float64 `json:"highest_price"`
+	LowestPrice           float64 `json:"lowest_price"`
+	MaxAdverseExcursion   float64 `json:"max_adverse_excursion"`
+	MaxFavorableExcursion float64 `json:"max_favorable_excursion"`
+	BarsHeld              int     `json:"bars_held"`
+	EntryEfficiency       float64 `json:"entry_efficiency"`
+	ExitEfficiency        float64 `json:"exit_efficiency"`
+}
+
+// RecordPrice updates the price extremes of an open position with a candle
+func (t *Transaction) RecordPrice(high, low float64) {
+	t.HighestPrice = max(t.HighestPrice, high)
+	t.LowestPrice = min(t.LowestPrice, low)
+}
+
+// RecordExcursions calculates the excursions and efficiencies once the position is sold
+func (t *Transaction) RecordExcursions(barsHeld int) {
+	t.BarsHeld = barsHeld
+	if t.PriceBought > 0 {
+		t.MaxAdverseExcursion = (t.PriceBought - t.LowestPrice) / t.PriceBought
+		t.MaxFavorableExcursion = (t.HighestPrice - t.PriceBought) / t.PriceBought
+	}
+
+	priceRange := t.HighestPrice - t.LowestPrice
+	if priceRange > 0 {
+		t.EntryEfficiency = (t.HighestPrice - t.PriceBought) / priceRange
+		t.ExitEfficiency = (t.PriceSold - t.LowestPrice) / priceRange
+	}
 }
@@ -11,20 +11,66 @@ type TrendValues struct {
 }
 
 type Trend struct {
-	TrendID               string       `bigquery:"trend_id"`
-	Stock                 string       `bigquery:"stock"`
-	TrendScore            float64      `bigquery:"trend_score"`
-	Date                  time.Time    `bigquery:"date"`
-	IndicatorBuyScenario  BuyScenario  `bigquery:"indicator_buy_scenario"`
-	IndicatorSellScenario SellScenario `bigquery:"indicator_sell_scenario"`
+	TrendID               string            `bigquery:"trend_id"`
+	Stock                 string            `bigquery:"stock"`
+	ScenarioName          string            `bigquery:"scenario_name"`
+	Interval              string            `bigquery:"interval"` // Bar interval of the data: 1m, 5m, 1h or 1d
+	Prices                string            `bigquery:"prices"`   // raw, or adjusted for splits and dividends
+	TrendScore            float64           `bigquery:"trend_score"`
+	ScoringProfile        string            `bigquery:"scoring_profile"` // Scorer that produced TrendScore
+	PValue                float64           `bigquery:"p_value"`         // Adjusted for the other scenarios of the same run
+	Significant           bool              `bigquery:"significant"`
+	YearlyProfit          float64           `bigquery:"yearly_profit"` // Annualized return over the period of the data, 0.1 is 10%. The total return for less than a quarter year.
+	Date                  time.Time         `bigquery:"date"`
+	PeriodStart           time.Time         `bigquery:"period_start"` // First candle of the data the trend was run on
+	PeriodEnd             time.Time         `bigquery:"period_end"`
+	IndicatorBuyScenario  BuyScenario       `bigquery:"indicator_buy_scenario"`
+	IndicatorSellScenario SellScenario      `bigquery:"indicator_sell_scenario"`
+	CustomIndicators      []CustomIndicator `bigquery:"custom_indicators"`
 }
 
 type TrendResponse struct {
-	ID                    int64        `json:"id"`
-	Stock                 string       `json:"stock"`
-	TrendScore            float64      `json:"trend_score"`
-	Date                  time.Time    `json:"date"`
-	TrendValues           TrendValues  `json:"trend_values"`
-	IndicatorBuyScenario  BuyScenario  `json:"indicator_buy_scenario"`
-	IndicatorSellScenario SellScenario `json:"indicator_sell_scenario"`
+	ID                    int64             `json:"id"`
+	Stock                 string            `json:"stock"`
+	ScenarioName          string            `json:"scenario_name"`
+	Interval              string            `json:"interval"`
+	Prices                string            `json:"prices"`
+	TrendScore            float64           `json:"trend_score"`
+	ScoringProfile        string            `json:"scoring_profile"`
+	PValue                float64           `json:"p_value"`
+	Significant           bool              `json:"significant"`
+	YearlyProfit          float64           `json:"yearly_profit"`
+	Date                  time.Time         `json:"date"`
+	PeriodStart           time.Time         `json:"period_start"`
+	PeriodEnd             time.Time         `json:"period_end"`
+	TrendValues           TrendValues       `json:"trend_values"`
+	IndicatorBuyScenario  BuyScenario       `json:"indicator_buy_scenario"`
+	IndicatorSellScenario SellScenario      `json:"indicator_sell_scenario"`
+	CustomIndicators      []CustomIndicator `json:"custom_indicators"`
+}
+
+// ScenarioResult is the outcome of one scenario in a /checkMarket run
+type ScenarioResult struct {
+	TrendID        string  `json:"trend_id"`
+	ScenarioName   string  `json:"scenario_name"`
+	Interval       string  `json:"interval"`
+	TrendScore     float64 `json:"trend_score"`
+	ScoringProfile string  `json:"scoring_profile"`
+	YearlyProfit   float64 `json:"yearly_profit"`
+	PValue         float64 `json:"p_value"`
+	Significant    bool    `json:"significant"`
+	Transactions   int     `json:"transactions"`
+	Saved          bool    `json:"saved"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// CheckMarketResponse is returned by /checkMarket, with the quality of the data the scenarios ran on.
+// AdjustmentError says why the scenarios ran on raw prices when adjusted prices were asked for.
+type CheckMarketResponse struct {
+	Symbol          string           `json:"symbol"`
+	Interval        string           `json:"interval"`
+	Prices          string           `json:"prices"`
+	AdjustmentError string           `json:"adjustment_error,omitempty"`
+	Quality         QualityReport    `json:"quality"`
+	Results         []ScenarioResult `json:"results"`
 }
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"math"
+)
+
+// RelativeVolume returns the volume of each bar divided by the average volume of the
+// preceding period bars, so 2.0 means twice the usual volume.
+func RelativeVolume(volume []float64, period int) []float64 {
+	out := make([]float64, len(volume))
+	if period <= 0 {
+		return out
+	}
+
+	sum := 0.0
+	for i := range volume {
+		if i >= period {
+			average := sum / float64(period)
+			if average > 0 {
+				out[i] = volume[i] / average
+			}
+			sum -= volume[i-period]
+		}
+		sum += volume[i]
+	}
+	return out
+}
+
+// VWAP calculates the volume weighted average price of the typical price, resetting at the
+// bars marked in sessionStarts (the first bar when it is nil). The returned deviation is the
+// volume weighted standard deviation around the VWAP and is used to build the VWAP bands.
+func VWAP(data []IntradayData, sessionStarts []bool) (vwap []float64, deviation []float64) {
+	vwap = make([]float64, len(data))
+	deviation = make([]float64, len(data))
+
+	var cumulativeVolume, cumulativePV, cumulativePV2 float64
+	for i, entry := range data {
+		if i == 0 || (i < len(sessionStarts) && sessionStarts[i]) {
+			cumulativeVolume, cumulativePV, cumulativePV2 = 0, 0, 0
+		}
+
+		typicalPrice := (entry.High + entry.Low + entry.Close) / 3
+		volume := float64(entry.Volume)
+		cumulativeVolume += volume
+		cumulativePV += typicalPrice * volume
+		cumulativePV2 += typicalPrice * typicalPrice * volume
+
+		// No volume traded yet this session, fall back to the typical price
+		if cumulativeVolume == 0 {
+			vwap[i] = typicalPrice
+			continue
+		}
+
+		vwap[i] = cumulativePV / cumulativeVolume
+		variance := cumulativePV2/cumulativeVolume - vwap[i]*vwap[i]
+		if variance > 0 {
+			deviation[i] = math.Sqrt(variance)
+		}
+	}
+
+	return vwap, deviation
+}
+
+// VWAPBand returns the VWAP shifted by multiplier standard deviations (negative for the lower band)
+func VWAPBand(vwap, deviation []float64, multiplier float64) []float64 {
+	out := make([]float64, len(vwap))
+	for i := range vwap {
+		out[i] = vwap[i] + multiplier*deviation[i]
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVWAPResetsAtSessionOpen(t *testing.T) {
+	// 2025-06-17 and 2025-06-18 at 13:30 UTC (9:30 ET)
+	day1 := int64(1750167000)
+	day2 := int64(1750253400)
+
+	data := []IntradayData{
+		{Timestamp: day1, High: 10, Low: 10, Close: 10, Volume: 100},
+		{Timestamp: day1 + 60, High: 20, Low: 20, Close: 20, Volume: 100},
+		{Timestamp: day2, High: 30, Low: 30, Close: 30, Volume: 50},
+	}
+
+	vwap, deviation := VWAP(data, []bool{true, false, true})
+
+	if vwap[1] != 15 {
+		t.Errorf("Expected VWAP 15 within first session; got: %.2f", vwap[1])
+	}
+	if math.Abs(deviation[1]-5) > 1e-9 {
+		t.Errorf("Expected deviation 5 within first session; got: %.2f", deviation[1])
+	}
+	if vwap[2] != 30 {
+		t.Errorf("Expected VWAP to reset to 30 at new session; got: %.2f", vwap[2])
+	}
+	if deviation[2] != 0 {
+		t.Errorf("Expected deviation to reset at new session; got: %.2f", deviation[2])
+	}
+}
+
+func TestVWAPWithoutSessionStarts(t *testing.T) {
+	data := []IntradayData{
+		{Timestamp: 0, High: 10, Low: 10, Close: 10, Volume: 100},
+		{Timestamp: 86400, High: 20, Low: 20, Close: 20, Volume: 100},
+	}
+
+	// Without session starts all data is one session, however long the pause
+	vwap, _ := VWAP(data, nil)
+	if vwap[1] != 15 {
+		t.Errorf("Expected VWAP 15 over one session; got: %.2f", vwap[1])
+	}
+}
+
+func TestRelativeVolume(t *testing.T) {
+	rvol := RelativeVolume([]float64{100, 100, 200, 50}, 2)
+
+	expected := []float64{0, 0, 2, 1.0 / 3}
+	for i := range expected {
+		if math.Abs(rvol[i]-expected[i]) > 1e-9 {
+			t.Errorf("Expected relative volume %.2f at %d; got: %.2f", expected[i], i, rvol[i])
+		}
+	}
+}
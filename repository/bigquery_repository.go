@@ -2,12 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"log"
 	"trend-hencher-api/models"
 
 	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
 )
 
+// ErrTrendNotFound is returned when no trend has the requested ID
+var ErrTrendNotFound = errors.New("trend not found")
+
 type BigQueryRepository struct {
 	client *bigquery.Client
 	ctx    context.Context
@@ -47,3 +52,55 @@ func (r *BigQueryRepository) SaveTransactions(transactions []models.Transaction)
 
 	return nil
 }
+
+// GetTrend retrieves the Trend with the given trend ID
+func (r *BigQueryRepository) GetTrend(trendID string) (*models.Trend, error) {
+	query := r.client.Query("SELECT * FROM `trend_dataset.Trend` WHERE trend_id = @trend_id LIMIT 1")
+	query.Parameters = []bigquery.QueryParameter{{Name: "trend_id", Value: trendID}}
+
+	it, err := query.Read(r.ctx)
+	if err != nil {
+		log.Printf("Failed to query trend in BigQuery: %v", err)
+		return nil, err
+	}
+
+	var trend models.Trend
+	err = it.Next(&trend)
+	if err == iterator.Done {
+		return nil, ErrTrendNotFound
+	}
+	if err != nil {
+		log.Printf("Failed to read trend from BigQuery: %v", err)
+		return nil, err
+	}
+
+	return &trend, nil
+}
+
+// GetTransactions retrieves the transactions of a trend in the order they were made
+func (r *BigQueryRepository) GetTransactions(trendID string) ([]models.Transaction, error) {
+	query := r.client.Query("SELECT * FROM `trend_dataset.Transaction` WHERE trend_id = @trend_id ORDER BY date_bought")
+	query.Parameters = []bigquery.QueryParameter{{Name: "trend_id", Value: trendID}}
+
+	it, err := query.Read(r.ctx)
+	if err != nil {
+		log.Printf("Failed to query transactions in BigQuery: %v", err)
+		return nil, err
+	}
+
+	transactions := []models.Transaction{}
+	for {
+		var transaction models.Transaction
+		err := it.Next(&transaction)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Printf("Failed to read transactions from BigQuery: %v", err)
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
@@ -58,10 +58,20 @@ func (r *DatastoreRepository) GetAllTrends() ([]models.TrendResponse, error) {
 		response = append(response, models.TrendResponse{
 			ID:                    key.ID,
 			Stock:                 trends[i].Stock,
+			ScenarioName:          trends[i].ScenarioName,
+			Interval:              trends[i].Interval,
+			Prices:                trends[i].Prices,
 			TrendScore:            trends[i].TrendScore,
+			ScoringProfile:        trends[i].ScoringProfile,
+			PValue:                trends[i].PValue,
+			Significant:           trends[i].Significant,
+			YearlyProfit:          trends[i].YearlyProfit,
 			Date:                  trends[i].Date,
+			PeriodStart:           trends[i].PeriodStart,
+			PeriodEnd:             trends[i].PeriodEnd,
 			IndicatorBuyScenario:  trends[i].IndicatorBuyScenario,
 			IndicatorSellScenario: trends[i].IndicatorSellScenario,
+			CustomIndicators:      trends[i].CustomIndicators,
 		})
 	}
 	return response, nil
@@ -88,6 +98,14 @@ func (r *DatastoreRepository) GetTransactions(trendID int64) ([]models.Transacti
 			PriceBought: transactions[i].PriceBought,
 			PriceSold:   transactions[i].PriceSold,
 			Volume:      transactions[i].Volume,
+
+			HighestPrice:          transactions[i].HighestPrice,
+			LowestPrice:           transactions[i].LowestPrice,
+			MaxAdverseExcursion:   transactions[i].MaxAdverseExcursion,
+			MaxFavorableExcursion: transactions[i].MaxFavorableExcursion,
+			BarsHeld:              transactions[i].BarsHeld,
+			EntryEfficiency:       transactions[i].EntryEfficiency,
+			ExitEfficiency:        transactions[i].ExitEfficiency,
 		})
 	}
 	return response, nil
@@ -20,3 +20,11 @@ func (s *BigQueryTrendService) SaveTrend(trend *models.Trend) error {
 func (s *BigQueryTrendService) SaveTransactions(transactions []models.Transaction) error {
 	return s.repo.SaveTransactions(transactions)
 }
+
+func (s *BigQueryTrendService) GetTrend(trendID string) (*models.Trend, error) {
+	return s.repo.GetTrend(trendID)
+}
+
+func (s *BigQueryTrendService) GetTransactions(trendID string) ([]models.Transaction, error) {
+	return s.repo.GetTransactions(trendID)
+}